@@ -1,46 +1,83 @@
 package animations
 
+// What an animation does after its last frame.
+type LoopMode string
+
+const (
+	Loop     LoopMode = "loop"     // start over from the first frame
+	Once     LoopMode = "once"     // stay on the last frame
+	PingPong LoopMode = "pingpong" // play backwards to the first frame, then forwards again
+)
+
 type Animation struct {
-	First        int // Frames
-	Last         int
-	Step         int     // How many indices do we move per frame
-	SpeedInTips  float32 // How many ticks before next frame
+	Frames    []int     // indices into the spritesheet, in play order
+	Durations []float32 // how many ticks each frame shows for
+	Loop      LoopMode
+
 	frameCounter float32
-	frame        int
+	index        int // into Frames
+	direction    int // 1 forwards, -1 backwards for ping pong
 }
 
 func (a *Animation) Update() {
 	a.frameCounter -= 1.0
-	if a.frameCounter < 0.0 {
-		a.frameCounter = a.SpeedInTips
-		a.frame += a.Step
-		if a.frame > a.Last {
+	if a.frameCounter > 0.0 {
+		return
+	}
+
+	next := a.index + a.direction
+	if next < 0 || next >= len(a.Frames) {
+		switch a.Loop {
+		case Once:
+			next = a.index
+		case PingPong:
+			a.direction = -a.direction
+			next = a.index + a.direction
+		default:
 			// loop back to the beginning
-			a.frame = a.First
+			next = 0
 		}
 	}
+
+	// a single frame ping pong has nowhere to go
+	a.index = max(0, min(next, len(a.Frames)-1))
+	a.frameCounter += a.Durations[a.index]
 }
 
 func (a *Animation) Frame() int {
-	return a.frame
+	return a.Frames[a.index]
 }
 
+// NewAnimation plays every step-th frame from first to last, each for speed+1 ticks, looping.
 func NewAnimation(first, last, step int, speed float32) *Animation {
-	return &Animation{
-		first,
-		last,
-		step,
-		speed,
-		speed,
-		first,
+	frames := make([]int, 0)
+	durations := make([]float32, 0)
+	for frame := first; frame <= last; frame += step {
+		frames = append(frames, frame)
+		durations = append(durations, speed+1)
+	}
+	return NewClip(frames, durations, Loop)
+}
+
+// NewClip plays frames, each for its duration in ticks, in the given loop mode.
+// frames and durations must be the same length and not empty.
+func NewClip(frames []int, durations []float32, loop LoopMode) *Animation {
+	a := &Animation{
+		Frames:    frames,
+		Durations: durations,
+		Loop:      loop,
 	}
+	a.Reset()
+	return a
 }
 
+// IsLastFrame is true on the last frame of the play order, for ping pong that's the last one in Frames.
 func (a *Animation) IsLastFrame() bool {
-	return a.frame >= a.Last
+	return a.index == len(a.Frames)-1
 }
 
 func (a *Animation) Reset() {
-	a.frame = a.First
-	a.frameCounter = a.SpeedInTips // Reset timing as well
+	a.index = 0
+	a.direction = 1
+	a.frameCounter = a.Durations[0] // Reset timing as well
 }
@@ -0,0 +1,163 @@
+package animations
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// frames runs the animation for n ticks and returns the frame shown after each.
+func frames(a *Animation, n int) []int {
+	shown := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		a.Update()
+		shown = append(shown, a.Frame())
+	}
+	return shown
+}
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		name      string
+		frames    []int
+		durations []float32
+		loop      LoopMode
+		want      []int
+	}{
+		{
+			name:      "loop",
+			frames:    []int{4, 5, 6},
+			durations: []float32{2, 2, 2},
+			loop:      Loop,
+			want:      []int{4, 5, 5, 6, 6, 4, 4, 5},
+		},
+		{
+			name:      "once",
+			frames:    []int{4, 5, 6},
+			durations: []float32{1, 1, 1},
+			loop:      Once,
+			want:      []int{5, 6, 6, 6, 6},
+		},
+		{
+			name:      "ping pong",
+			frames:    []int{4, 5, 6},
+			durations: []float32{1, 1, 1},
+			loop:      PingPong,
+			want:      []int{5, 6, 5, 4, 5, 6, 5},
+		},
+		{
+			name:      "ping pong on one frame",
+			frames:    []int{9},
+			durations: []float32{1},
+			loop:      PingPong,
+			want:      []int{9, 9, 9},
+		},
+		{
+			name:      "frames of their own length",
+			frames:    []int{1, 2},
+			durations: []float32{1, 3},
+			loop:      Loop,
+			want:      []int{2, 2, 2, 1, 2, 2},
+		},
+	}
+
+	for _, test := range tests {
+		a := NewClip(test.frames, test.durations, test.loop)
+		got := frames(a, len(test.want))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: frames %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestNewAnimation(t *testing.T) {
+	a := NewAnimation(26, 30, 3, 1)
+	if !reflect.DeepEqual(a.Frames, []int{26, 29}) {
+		t.Errorf("frames %v, want [26 29]", a.Frames)
+	}
+
+	// speed+1 ticks a frame, looping
+	got := frames(a, 6)
+	want := []int{26, 29, 29, 26, 26, 29}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("frames %v, want %v", got, want)
+	}
+}
+
+func TestIsLastFrameAndReset(t *testing.T) {
+	a := NewClip([]int{12, 13, 14}, []float32{1, 1, 1}, Once)
+	if a.IsLastFrame() {
+		t.Error("last frame before playing")
+	}
+
+	frames(a, 2)
+	if !a.IsLastFrame() {
+		t.Errorf("not on the last frame after playing, on %d", a.Frame())
+	}
+
+	a.Reset()
+	if a.Frame() != 12 || a.IsLastFrame() {
+		t.Errorf("after Reset on frame %d", a.Frame())
+	}
+}
+
+func TestLoadCharacterErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		clips    string
+		required []string
+		want     string // part of the error
+	}{
+		{
+			name:     "missing clip",
+			clips:    `"right": {"frames": [7, 8], "duration": 150}`,
+			required: []string{"right", "attack"},
+			want:     `no "attack" clip`,
+		},
+		{
+			name:  "no frames",
+			clips: `"right": {"frames": [], "duration": 150}`,
+			want:  `clip "right" has no frames`,
+		},
+		{
+			name:  "durations don't match the frames",
+			clips: `"right": {"frames": [7, 8], "durations": [100]}`,
+			want:  `clip "right" has 2 frames but 1 durations`,
+		},
+		{
+			name:  "no duration",
+			clips: `"right": {"frames": [7, 8]}`,
+			want:  `clip "right" has no duration`,
+		},
+		{
+			name:  "unknown loop mode",
+			clips: `"idle": {"frames": [1, 2], "duration": 150, "loop": "bounce"}`,
+			want:  `clip "idle" has unknown loop mode "bounce"`,
+		},
+		{
+			name:  "misspelled loop mode",
+			clips: `"idle": {"frames": [1, 2], "duration": 150, "loop": "ping-pong"}`,
+			want:  `clip "idle" has unknown loop mode "ping-pong"`,
+		},
+	}
+
+	for _, test := range tests {
+		definition := filepath.Join(t.TempDir(), "goblin.json")
+		err := os.WriteFile(definition, []byte(`{
+			"sheet": "goblin.png",
+			"frameWidth": 192,
+			"frameHeight": 192,
+			"clips": {`+test.clips+`}
+		}`), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = LoadCharacter(definition, test.required...)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: LoadCharacter error %v, want one containing %s", test.name, err, test.want)
+		}
+	}
+}
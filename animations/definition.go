@@ -0,0 +1,139 @@
+package animations
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"rpg-game-go/spritesheet"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// A named clip in a definition file. Durations are in milliseconds, either
+// one per frame or a single Duration for all of them.
+type ClipJSON struct {
+	Frames    []int     `json:"frames"`
+	Durations []float64 `json:"durations"`
+	Duration  float64   `json:"duration"`
+	Loop      LoopMode  `json:"loop"` // defaults to Loop
+}
+
+// The animation definition file of a character.
+type DefinitionJSON struct {
+	Sheet       string              `json:"sheet"` // relative to the definition file
+	FrameWidth  int                 `json:"frameWidth"`
+	FrameHeight int                 `json:"frameHeight"`
+	Columns     int                 `json:"columns"` // worked out from the image when left out
+	FootY       int                 `json:"footY"`   // how far down a frame the feet are, the bottom of the frame when left out
+	Clips       map[string]ClipJSON `json:"clips"`
+}
+
+// Character is a loaded definition: the sheet image and how to cut it, plus
+// the clips to animate it with.
+type Character struct {
+	Img   *ebiten.Image
+	Sheet *spritesheet.Spritesheet
+	FootY int // how far down a frame the feet are, in pixels
+	clips map[string]ClipJSON
+}
+
+// LoadCharacter reads an animation definition file and the sheet it points at.
+// required are the clips the character can't do without, missing any of them
+// is an error.
+func LoadCharacter(filepath string, required ...string) (*Character, error) {
+	contents, err := os.ReadFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	var definition DefinitionJSON
+	err = json.Unmarshal(contents, &definition)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath, err)
+	}
+
+	if definition.FrameWidth <= 0 || definition.FrameHeight <= 0 {
+		return nil, fmt.Errorf("%s: no frame size", filepath)
+	}
+	for _, name := range required {
+		if _, exists := definition.Clips[name]; !exists {
+			return nil, fmt.Errorf("%s: no %q clip", filepath, name)
+		}
+	}
+	for name, clip := range definition.Clips {
+		if len(clip.Frames) == 0 {
+			return nil, fmt.Errorf("%s: clip %q has no frames", filepath, name)
+		}
+		if len(clip.Durations) != 0 && len(clip.Durations) != len(clip.Frames) {
+			return nil, fmt.Errorf("%s: clip %q has %d frames but %d durations", filepath, name, len(clip.Frames), len(clip.Durations))
+		}
+		if len(clip.Durations) == 0 && clip.Duration <= 0 {
+			return nil, fmt.Errorf("%s: clip %q has no duration", filepath, name)
+		}
+		switch clip.Loop {
+		case "", Loop, Once, PingPong:
+		default:
+			return nil, fmt.Errorf("%s: clip %q has unknown loop mode %q", filepath, name, clip.Loop)
+		}
+	}
+
+	img, _, err := ebitenutil.NewImageFromFile(path.Join(path.Dir(filepath), definition.Sheet))
+	if err != nil {
+		return nil, err
+	}
+
+	columns := definition.Columns
+	if columns <= 0 {
+		columns = img.Bounds().Dx() / definition.FrameWidth
+	}
+
+	footY := definition.FootY
+	if footY <= 0 {
+		footY = definition.FrameHeight
+	}
+
+	return &Character{
+		Img: img,
+		Sheet: &spritesheet.Spritesheet{
+			WidthInTiles:  columns,
+			HeightInTiles: img.Bounds().Dy() / definition.FrameHeight,
+			TileWidth:     definition.FrameWidth,
+			TileHeight:    definition.FrameHeight,
+		},
+		FootY: footY,
+		clips: definition.Clips,
+	}, nil
+}
+
+// Milliseconds to ticks, at least one so every frame shows.
+func ticks(ms float64) float32 {
+	return float32(math.Max(1, math.Round(ms*float64(ebiten.TPS())/1000)))
+}
+
+// Clip builds a new animation from the named clip, nil if there is no such
+// clip. Every call returns its own animation, so characters sharing a
+// definition animate independently.
+func (c *Character) Clip(name string) *Animation {
+	clip, exists := c.clips[name]
+	if !exists {
+		return nil
+	}
+
+	durations := make([]float32, 0, len(clip.Frames))
+	for i := range clip.Frames {
+		ms := clip.Duration
+		if len(clip.Durations) != 0 {
+			ms = clip.Durations[i]
+		}
+		durations = append(durations, ticks(ms))
+	}
+
+	loop := clip.Loop
+	if loop == "" {
+		loop = Loop
+	}
+	return NewClip(clip.Frames, durations, loop)
+}
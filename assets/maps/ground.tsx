@@ -1,4 +1,4 @@
 <?xml version="1.0" encoding="UTF-8"?>
 <tileset version="1.10" tiledversion="1.11.2" name="grounds" tilewidth="16" tileheight="16" tilecount="640" columns="40">
- <image source="../../../assets_for_projects/Tiny Swords - asset pack/Tiny Swords/Terrain/Ground/Tilemap_Flat.png" width="640" height="256"/>
+ <image source="../images/Tilemap_Flat.png" width="640" height="256"/>
 </tileset>
@@ -1,27 +1,191 @@
 package camera
 
-import "math"
+import (
+	"image"
+	"math"
 
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// FollowMode controls how Follow chases its target. The zero value snaps to
+// the target every tick, like FollowTarget.
+type FollowMode struct {
+	// How quickly the camera catches up, per second. The distance left shrinks
+	// exponentially, so higher is snappier. 0 snaps.
+	Smoothing float64
+	// Size of the box around the center of the screen the target can move in
+	// without the camera scrolling, in pixels.
+	DeadzoneWidth  float64
+	DeadzoneHeight float64
+	// How many ticks of the target's velocity the camera looks ahead by.
+	LookAhead float64
+}
+
+// X and Y move the world so the camera's center lands in the middle of the
+// screen, Zoom and Rotation then apply around the middle of the screen.
 type Camera struct {
-	X, Y float64
+	X, Y     float64
+	Zoom     float64 // 2 draws everything twice as big
+	Rotation float64 // radians, clockwise
+	Mode     FollowMode
+	Shake    ShakeMode
+
+	shake  shake
+	script *script // nil unless a path is playing
+	queued []*Path // paths to play once the one playing is done
+
+	// point the camera centers on before look-ahead, it only moves when the target leaves the deadzone
+	focusX, focusY float64
+	hasFocus       bool
 }
 
 func NewCamera(x, y float64) *Camera {
 	return &Camera{
-		X: x,
-		Y: y,
+		X:    x,
+		Y:    y,
+		Zoom: 1,
 	}
 }
 
+// FollowTarget snaps the view onto the target, ignoring Mode. Use it when the
+// target jumps, like going through a portal.
 func (c *Camera) FollowTarget(targetX, targetY, screenWidth, screenHeight float64) {
 	c.X = -targetX + screenWidth/2.0
 	c.Y = -targetY + screenHeight/2.0
+
+	c.focusX, c.focusY = targetX, targetY
+	c.hasFocus = true
+}
+
+// Follow moves the view toward the target once a tick, as set by Mode, or
+// along the path being played. dx and dy are the target's velocity in pixels
+// per tick.
+func (c *Camera) Follow(targetX, targetY, dx, dy, screenWidth, screenHeight float64) {
+	if c.script != nil {
+		x, y, done := c.script.update(targetX, targetY)
+		c.X = -x + screenWidth/2.0
+		c.Y = -y + screenHeight/2.0
+
+		if done {
+			c.script = nil
+			c.focusX, c.focusY = targetX, targetY
+
+			if len(c.queued) > 0 {
+				c.Play(c.queued[0])
+				c.queued = c.queued[1:]
+			}
+		}
+		return
+	}
+
+	if !c.hasFocus {
+		c.FollowTarget(targetX, targetY, screenWidth, screenHeight)
+		return
+	}
+
+	// drag the focus along just enough to keep the target inside the deadzone
+	halfW, halfH := c.Mode.DeadzoneWidth/2.0, c.Mode.DeadzoneHeight/2.0
+	c.focusX = math.Max(math.Min(c.focusX, targetX+halfW), targetX-halfW)
+	c.focusY = math.Max(math.Min(c.focusY, targetY+halfH), targetY-halfH)
+
+	wantX := -(c.focusX + dx*c.Mode.LookAhead) + screenWidth/2.0
+	wantY := -(c.focusY + dy*c.Mode.LookAhead) + screenHeight/2.0
+
+	if c.Mode.Smoothing <= 0 {
+		c.X, c.Y = wantX, wantY
+		return
+	}
+
+	// frame rate independent exponential smoothing
+	t := 1 - math.Exp(-c.Mode.Smoothing/float64(ebiten.TPS()))
+	c.X += (wantX - c.X) * t
+	c.Y += (wantY - c.Y) * t
 }
 
 func (c *Camera) Constrain(tilemapWidthPixels, tilemapheightPixels, screenWidth, screenHeight float64) {
-	c.X = math.Min(c.X, 0.0)
-	c.Y = math.Min(c.Y, 0.0)
+	c.ConstrainBounds(image.Rect(0, 0, int(tilemapWidthPixels), int(tilemapheightPixels)), screenWidth, screenHeight)
+}
+
+// ConstrainBounds keeps the view inside bounds, which may start anywhere (infinite maps grow into negative coordinates).
+// The view is smaller when zoomed in and turns with the camera, so the limits depend on both.
+func (c *Camera) ConstrainBounds(bounds image.Rectangle, screenWidth, screenHeight float64) {
+	// shake is allowed to show past the edge for a moment
+	halfW, halfH := c.halfView(screenWidth, screenHeight, c.Rotation)
+
+	// the world point in the middle of the screen
+	centerX := -c.X + screenWidth/2.0
+	centerY := -c.Y + screenHeight/2.0
 
-	c.X = math.Max(c.X, screenWidth - tilemapWidthPixels)
-	c.Y = math.Max(c.Y, screenHeight - tilemapheightPixels)
-}
\ No newline at end of file
+	centerX = math.Max(centerX, float64(bounds.Min.X)+halfW)
+	centerY = math.Max(centerY, float64(bounds.Min.Y)+halfH)
+
+	centerX = math.Min(centerX, float64(bounds.Max.X)-halfW)
+	centerY = math.Min(centerY, float64(bounds.Max.Y)-halfH)
+
+	c.X = -centerX + screenWidth/2.0
+	c.Y = -centerY + screenHeight/2.0
+}
+
+func (c *Camera) zoom() float64 {
+	if c.Zoom <= 0 {
+		return 1
+	}
+	return c.Zoom
+}
+
+// Half the size of the world area the screen covers at angle, or of the box around it when rotated.
+func (c *Camera) halfView(screenWidth, screenHeight, angle float64) (float64, float64) {
+	cos := math.Abs(math.Cos(angle))
+	sin := math.Abs(math.Sin(angle))
+
+	halfW := (cos*screenWidth + sin*screenHeight) / (2 * c.zoom())
+	halfH := (sin*screenWidth + cos*screenHeight) / (2 * c.zoom())
+	return halfW, halfH
+}
+
+// Lens rotates and zooms around the middle of the screen, and shakes it. It
+// applies after the camera's translation, which parallax layers work out for
+// themselves.
+func (c *Camera) Lens(screenWidth, screenHeight float64) ebiten.GeoM {
+	geom := ebiten.GeoM{}
+	geom.Translate(-screenWidth/2.0, -screenHeight/2.0)
+	geom.Rotate(c.Rotation + c.shake.angle)
+	geom.Scale(c.zoom(), c.zoom())
+	geom.Translate(screenWidth/2.0+c.shake.offsetX, screenHeight/2.0+c.shake.offsetY)
+	return geom
+}
+
+// GeoM takes world pixels to screen pixels. Concat it onto the GeoM of whatever is drawn in the world.
+func (c *Camera) GeoM(screenWidth, screenHeight float64) ebiten.GeoM {
+	geom := ebiten.GeoM{}
+	geom.Translate(c.X, c.Y)
+	geom.Concat(c.Lens(screenWidth, screenHeight))
+	return geom
+}
+
+func (c *Camera) WorldToScreen(x, y, screenWidth, screenHeight float64) (float64, float64) {
+	geom := c.GeoM(screenWidth, screenHeight)
+	return geom.Apply(x, y)
+}
+
+func (c *Camera) ScreenToWorld(x, y, screenWidth, screenHeight float64) (float64, float64) {
+	geom := c.GeoM(screenWidth, screenHeight)
+	geom.Invert()
+	return geom.Apply(x, y)
+}
+
+// View is the part of the world currently on screen, in world pixels. When
+// the camera is rotated it is the box around what is on screen.
+func (c *Camera) View(screenWidth, screenHeight float64) image.Rectangle {
+	halfW, halfH := c.halfView(screenWidth, screenHeight, c.Rotation+c.shake.angle)
+
+	// the shake moves the middle of the screen off the camera's center
+	centerX, centerY := c.ScreenToWorld(screenWidth/2.0, screenHeight/2.0, screenWidth, screenHeight)
+
+	return image.Rect(
+		int(math.Floor(centerX-halfW)),
+		int(math.Floor(centerY-halfH)),
+		int(math.Ceil(centerX+halfW)),
+		int(math.Ceil(centerY+halfH)),
+	)
+}
@@ -0,0 +1,194 @@
+package camera
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+const screenWidth, screenHeight = 320.0, 240.0
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestScreenToWorldRoundTrip(t *testing.T) {
+	cameras := []*Camera{
+		{X: 0, Y: 0, Zoom: 1},
+		{X: -480, Y: 215.5, Zoom: 1},
+		{X: -480, Y: 215.5, Zoom: 2.5},
+		{X: 100, Y: -30, Zoom: 0.5, Rotation: math.Pi / 6},
+		{X: 12, Y: 34, Zoom: 3, Rotation: -2},
+		// no zoom set counts as 1
+		{X: 12, Y: 34},
+	}
+	points := [][2]float64{{0, 0}, {160, 120}, {319, 239}, {-50, 400.25}}
+
+	for _, cam := range cameras {
+		for _, p := range points {
+			wx, wy := cam.ScreenToWorld(p[0], p[1], screenWidth, screenHeight)
+			sx, sy := cam.WorldToScreen(wx, wy, screenWidth, screenHeight)
+			if !near(sx, p[0]) || !near(sy, p[1]) {
+				t.Errorf("%+v: screen (%v, %v) -> world (%v, %v) -> screen (%v, %v)", *cam, p[0], p[1], wx, wy, sx, sy)
+			}
+		}
+	}
+}
+
+func TestScreenToWorld(t *testing.T) {
+	// the middle of the screen is the camera's center whatever the zoom and rotation
+	cam := &Camera{X: -340, Y: -80, Zoom: 2, Rotation: 1}
+	x, y := cam.ScreenToWorld(screenWidth/2, screenHeight/2, screenWidth, screenHeight)
+	if !near(x, 500) || !near(y, 200) {
+		t.Errorf("middle of the screen = (%v, %v), want (500, 200)", x, y)
+	}
+
+	// zoomed in twice, the top left corner is half the screen closer to the center
+	cam.Rotation = 0
+	x, y = cam.ScreenToWorld(0, 0, screenWidth, screenHeight)
+	if !near(x, 500-80) || !near(y, 200-60) {
+		t.Errorf("top left of the screen = (%v, %v), want (420, 140)", x, y)
+	}
+}
+
+func TestConstrainBounds(t *testing.T) {
+	bounds := image.Rect(-200, 0, 1400, 1000)
+
+	for _, zoom := range []float64{0.5, 1, 2, 4} {
+		// world points the camera tries to center on, past every edge and inside
+		for _, center := range [][2]float64{{-5000, 500}, {5000, 500}, {600, -5000}, {600, 5000}, {-5000, -5000}, {5000, 5000}, {600, 500}} {
+			cam := &Camera{Zoom: zoom}
+			cam.FollowTarget(center[0], center[1], screenWidth, screenHeight)
+			cam.ConstrainBounds(bounds, screenWidth, screenHeight)
+
+			view := cam.View(screenWidth, screenHeight)
+			if !view.In(bounds) {
+				t.Errorf("zoom %v, center %v: view %v leaves %v", zoom, center, view, bounds)
+			}
+
+			// the view is as big as the zoom says and touches the edge it was pushed against
+			halfW, halfH := screenWidth/2/zoom, screenHeight/2/zoom
+			x, y := cam.ScreenToWorld(screenWidth/2, screenHeight/2, screenWidth, screenHeight)
+			wantX := math.Min(math.Max(center[0], float64(bounds.Min.X)+halfW), float64(bounds.Max.X)-halfW)
+			wantY := math.Min(math.Max(center[1], float64(bounds.Min.Y)+halfH), float64(bounds.Max.Y)-halfH)
+			if !near(x, wantX) || !near(y, wantY) {
+				t.Errorf("zoom %v, center %v: constrained to (%v, %v), want (%v, %v)", zoom, center, x, y, wantX, wantY)
+			}
+		}
+	}
+}
+
+func TestConstrainBoundsRotated(t *testing.T) {
+	bounds := image.Rect(0, 0, 2000, 2000)
+
+	for _, zoom := range []float64{0.5, 1, 2} {
+		cam := &Camera{Zoom: zoom, Rotation: math.Pi / 4}
+		cam.FollowTarget(0, 0, screenWidth, screenHeight)
+		cam.ConstrainBounds(bounds, screenWidth, screenHeight)
+
+		// the box around the turned screen stays inside, so no corner shows past the edge
+		view := cam.View(screenWidth, screenHeight)
+		if !view.In(bounds) {
+			t.Errorf("zoom %v: rotated view %v leaves %v", zoom, view, bounds)
+		}
+	}
+}
+
+// The world point in the middle of the screen.
+func center(cam *Camera) (float64, float64) {
+	return -cam.X + screenWidth/2, -cam.Y + screenHeight/2
+}
+
+func TestFollowDeadzone(t *testing.T) {
+	cam := &Camera{Zoom: 1, Mode: FollowMode{DeadzoneWidth: 40, DeadzoneHeight: 20}}
+	cam.FollowTarget(100, 100, screenWidth, screenHeight)
+
+	// one after another, the deadzone is 20 pixels either side and 10 above and below
+	steps := []struct {
+		name             string
+		targetX, targetY float64
+		wantX, wantY     float64
+	}{
+		{"inside", 119, 109, 100, 100},
+		{"back inside", 81, 91, 100, 100},
+		{"on the edge", 120, 90, 100, 100},
+		{"past the right", 130, 95, 110, 100},
+		{"past the bottom", 125, 117, 110, 107},
+		{"past the left and top", 60, 80, 80, 90},
+		{"inside again", 70, 85, 80, 90},
+	}
+
+	for _, step := range steps {
+		cam.Follow(step.targetX, step.targetY, 0, 0, screenWidth, screenHeight)
+		x, y := center(cam)
+		if !near(x, step.wantX) || !near(y, step.wantY) {
+			t.Errorf("%s: target (%v, %v), center (%v, %v), want (%v, %v)", step.name, step.targetX, step.targetY, x, y, step.wantX, step.wantY)
+		}
+	}
+}
+
+func TestFollowLookAhead(t *testing.T) {
+	cam := &Camera{Zoom: 1, Mode: FollowMode{LookAhead: 10}}
+	cam.FollowTarget(100, 100, screenWidth, screenHeight)
+
+	cam.Follow(103, 98, 3, -2, screenWidth, screenHeight)
+	x, y := center(cam)
+	if !near(x, 103+3*10) || !near(y, 98-2*10) {
+		t.Errorf("center (%v, %v), want (%v, %v)", x, y, 103+3*10.0, 98-2*10.0)
+	}
+
+	// standing still, the camera comes back onto the target
+	cam.Follow(103, 98, 0, 0, screenWidth, screenHeight)
+	x, y = center(cam)
+	if !near(x, 103) || !near(y, 98) {
+		t.Errorf("stopped: center (%v, %v), want (103, 98)", x, y)
+	}
+}
+
+func TestFollowSmoothing(t *testing.T) {
+	cam := &Camera{Zoom: 1, Mode: FollowMode{Smoothing: 5}}
+	cam.FollowTarget(0, 0, screenWidth, screenHeight)
+
+	last := math.Hypot(200, 100)
+	for tick := 0; tick < 3*60; tick++ {
+		cam.Follow(200, 100, 0, 0, screenWidth, screenHeight)
+		x, y := center(cam)
+
+		// always closer, never past the target
+		left := math.Hypot(200-x, 100-y)
+		if left >= last || x > 200 || y > 100 {
+			t.Fatalf("tick %d: center (%v, %v), %v left after %v", tick, x, y, left, last)
+		}
+		last = left
+	}
+
+	if last > 0.01 {
+		t.Errorf("%v pixels left after 3 seconds", last)
+	}
+}
+
+func TestFollowConstrainBounds(t *testing.T) {
+	bounds := image.Rect(0, 0, 640, 480)
+	cam := &Camera{Zoom: 1, Mode: FollowMode{Smoothing: 8, DeadzoneWidth: 32, DeadzoneHeight: 32, LookAhead: 20}}
+	cam.FollowTarget(320, 240, screenWidth, screenHeight)
+
+	// run to the bottom right corner and past it, the look-ahead pulls the camera further out
+	x, y := 320.0, 240.0
+	for tick := 0; tick < 200; tick++ {
+		x += 4
+		y += 3
+		cam.Follow(x, y, 4, 3, screenWidth, screenHeight)
+		cam.ConstrainBounds(bounds, screenWidth, screenHeight)
+
+		view := cam.View(screenWidth, screenHeight)
+		if !view.In(bounds) {
+			t.Fatalf("tick %d: view %v leaves %v", tick, view, bounds)
+		}
+	}
+
+	// pinned to the corner
+	cx, cy := center(cam)
+	if !near(cx, 640-screenWidth/2) || !near(cy, 480-screenHeight/2) {
+		t.Errorf("center (%v, %v), want (%v, %v)", cx, cy, 640-screenWidth/2, 480-screenHeight/2)
+	}
+}
@@ -0,0 +1,39 @@
+package camera
+
+import (
+	"fmt"
+	"image"
+)
+
+// Culler skips drawing whatever is outside the view, and counts how much was
+// drawn and culled in the current frame.
+type Culler struct {
+	view   image.Rectangle
+	Drawn  int
+	Culled int
+}
+
+func NewCuller() *Culler {
+	return &Culler{}
+}
+
+// Reset starts a new frame. view is the part of the world on screen, see Camera.View.
+func (c *Culler) Reset(view image.Rectangle) {
+	c.view = view
+	c.Drawn = 0
+	c.Culled = 0
+}
+
+// Visible reports whether rect, in world pixels, is on screen and counts it as drawn or culled.
+func (c *Culler) Visible(rect image.Rectangle) bool {
+	if rect.Overlaps(c.view) {
+		c.Drawn++
+		return true
+	}
+	c.Culled++
+	return false
+}
+
+func (c *Culler) String() string {
+	return fmt.Sprintf("drawn: %d culled: %d", c.Drawn, c.Culled)
+}
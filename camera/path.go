@@ -0,0 +1,197 @@
+package camera
+
+import (
+	"fmt"
+	"math"
+	"rpg-game-go/objects"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Easing maps the time through a move, 0 to 1, to how far along it the camera is.
+type Easing func(t float64) float64
+
+func Linear(t float64) float64 {
+	return t
+}
+
+func EaseIn(t float64) float64 {
+	return t * t
+}
+
+func EaseOut(t float64) float64 {
+	return 1 - (1-t)*(1-t)
+}
+
+func EaseInOut(t float64) float64 {
+	if t < 0.5 {
+		return 2 * t * t
+	}
+	return 1 - 2*(1-t)*(1-t)
+}
+
+var easings = map[string]Easing{
+	"linear":    Linear,
+	"easein":    EaseIn,
+	"easeout":   EaseOut,
+	"easeinout": EaseInOut,
+}
+
+// A point the camera centers on, in world pixels.
+type Waypoint struct {
+	X, Y     float64
+	Duration float64 // seconds to get here from the previous waypoint
+	Hold     float64 // seconds to stay once here
+}
+
+// Path is a scripted camera move for cutscenes. The camera starts on the
+// first waypoint, moves through the rest and then eases back to the target
+// it was following.
+type Path struct {
+	Name      string
+	Waypoints []Waypoint
+	Easing    Easing
+	Handback  float64 // seconds to get back to the target after the last waypoint
+	Autoplay  bool    // play when the map loads
+}
+
+// Comma separated numbers, such as "2, 1.5, 3".
+func parseList(list string) ([]float64, error) {
+	values := make([]float64, 0)
+	if strings.TrimSpace(list) == "" {
+		return values, nil
+	}
+
+	for _, field := range strings.Split(list, ",") {
+		value, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// NewPath reads a camera path drawn as a polyline in Tiled. Its custom
+// properties set the timing, all in seconds:
+//
+//	duration   time for each move between waypoints (default 2)
+//	durations  comma separated times, one per move, overriding duration
+//	hold       time to stay on each waypoint (default 0)
+//	holds      comma separated times, one per waypoint, overriding hold
+//	easing     linear, easein, easeout or easeinout (default)
+//	handback   time to get back to the player at the end (default 1)
+//	autoplay   play the path when the map loads
+func NewPath(obj *objects.ObjectJSON) (*Path, error) {
+	if len(obj.Polyline) < 2 {
+		return nil, fmt.Errorf("camera: path %q needs a polyline with at least 2 points", obj.Name)
+	}
+
+	durations, err := parseList(obj.Properties.String("durations", ""))
+	if err != nil {
+		return nil, fmt.Errorf("camera: path %q durations: %w", obj.Name, err)
+	}
+	holds, err := parseList(obj.Properties.String("holds", ""))
+	if err != nil {
+		return nil, fmt.Errorf("camera: path %q holds: %w", obj.Name, err)
+	}
+
+	easing, exists := easings[obj.Properties.String("easing", "easeinout")]
+	if !exists {
+		return nil, fmt.Errorf("camera: path %q has unknown easing %q", obj.Name, obj.Properties.String("easing", ""))
+	}
+
+	path := &Path{
+		Name:      obj.Name,
+		Waypoints: make([]Waypoint, 0, len(obj.Polyline)),
+		Easing:    easing,
+		Handback:  obj.Properties.Float("handback", 1),
+		Autoplay:  obj.Properties.Bool("autoplay", false),
+	}
+
+	for i, point := range obj.Polyline {
+		waypoint := Waypoint{
+			X:        obj.X + point.X,
+			Y:        obj.Y + point.Y,
+			Duration: obj.Properties.Float("duration", 2),
+			Hold:     obj.Properties.Float("hold", 0),
+		}
+		// the first waypoint is where the path starts, there is no move into it
+		if i == 0 {
+			waypoint.Duration = 0
+		} else if i-1 < len(durations) {
+			waypoint.Duration = durations[i-1]
+		}
+		if i < len(holds) {
+			waypoint.Hold = holds[i]
+		}
+		path.Waypoints = append(path.Waypoints, waypoint)
+	}
+	return path, nil
+}
+
+// A path being played.
+type script struct {
+	path *Path
+	next int     // waypoint being moved to, len(Waypoints) during the handback
+	time float64 // seconds into the current move, including the hold after it
+}
+
+// Play detaches the camera from its target and moves it along path. Follow
+// picks the target back up once the path is done.
+func (c *Camera) Play(path *Path) {
+	c.script = &script{
+		path: path,
+		next: 0,
+		time: 0,
+	}
+}
+
+// Queue plays path once the paths already playing or queued are done, or
+// right away if there are none.
+func (c *Camera) Queue(path *Path) {
+	if c.script == nil {
+		c.Play(path)
+		return
+	}
+	c.queued = append(c.queued, path)
+}
+
+// Scripted is true while a path is playing.
+func (c *Camera) Scripted() bool {
+	return c.script != nil
+}
+
+// update advances the script a tick and returns the point to center on, and
+// whether the script is done. The target is where the handback goes.
+func (s *script) update(targetX, targetY float64) (float64, float64, bool) {
+	s.time += 1.0 / float64(ebiten.TPS())
+	waypoints := s.path.Waypoints
+
+	for s.next < len(waypoints) {
+		to := waypoints[s.next]
+		if s.time < to.Duration+to.Hold {
+			break
+		}
+		s.time -= to.Duration + to.Hold
+		s.next++
+	}
+
+	// moving from the previous waypoint, or from the last one back to the target
+	from := waypoints[max(s.next-1, 0)]
+	toX, toY, duration := targetX, targetY, s.path.Handback
+	if s.next < len(waypoints) {
+		to := waypoints[s.next]
+		toX, toY, duration = to.X, to.Y, to.Duration
+	} else if s.time >= duration {
+		return targetX, targetY, true
+	}
+
+	t := 1.0
+	if duration > 0 {
+		t = s.path.Easing(math.Min(s.time/duration, 1))
+	}
+	return from.X + (toX-from.X)*t, from.Y + (toY-from.Y)*t, false
+}
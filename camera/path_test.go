@@ -0,0 +1,129 @@
+package camera
+
+import (
+	"math"
+	"testing"
+)
+
+// tick runs the script for n ticks at 60 TPS and returns the last point.
+func tick(s *script, n int, targetX, targetY float64) (float64, float64, bool) {
+	var x, y float64
+	var done bool
+	for i := 0; i < n; i++ {
+		x, y, done = s.update(targetX, targetY)
+	}
+	return x, y, done
+}
+
+func TestScriptUpdate(t *testing.T) {
+	// one second to (100, 0), hold it for half a second, then half a second back to the target
+	path := &Path{
+		Waypoints: []Waypoint{
+			{X: 0, Y: 0},
+			{X: 100, Y: 0, Duration: 1, Hold: 0.5},
+		},
+		Easing:   Linear,
+		Handback: 0.5,
+	}
+	s := &script{path: path}
+	targetX, targetY := 200.0, 50.0
+
+	steps := []struct {
+		ticks int
+		x, y  float64
+	}{
+		{ticks: 1, x: 100.0 / 60, y: 0},
+		{ticks: 29, x: 50, y: 0},
+		{ticks: 30, x: 100, y: 0},
+		// holding
+		{ticks: 15, x: 100, y: 0},
+		{ticks: 15, x: 100, y: 0},
+		// handing back
+		{ticks: 15, x: 150, y: 25},
+	}
+
+	for i, step := range steps {
+		x, y, done := tick(s, step.ticks, targetX, targetY)
+		if done {
+			t.Fatalf("step %d: done early", i)
+		}
+		if math.Abs(x-step.x) > 1e-6 || math.Abs(y-step.y) > 1e-6 {
+			t.Errorf("step %d: at (%v, %v), want (%v, %v)", i, x, y, step.x, step.y)
+		}
+	}
+
+	// the handback finishes on the target
+	x, y, done := tick(s, 16, targetX, targetY)
+	if !done || x != targetX || y != targetY {
+		t.Errorf("end = (%v, %v) done %v, want (%v, %v) done", x, y, done, targetX, targetY)
+	}
+}
+
+func TestScriptUpdateEasingAndHolds(t *testing.T) {
+	path := &Path{
+		Waypoints: []Waypoint{
+			{X: 0, Y: 0, Hold: 1},
+			{X: 0, Y: 80, Duration: 1},
+			// no time to get here, it jumps
+			{X: 40, Y: 80, Duration: 0},
+		},
+		Easing:   EaseIn,
+		Handback: 1,
+	}
+	s := &script{path: path}
+
+	// holding on the first waypoint
+	x, y, _ := tick(s, 30, 0, 0)
+	if x != 0 || y != 0 {
+		t.Errorf("holding at (%v, %v), want (0, 0)", x, y)
+	}
+
+	// halfway through the move, eased in to a quarter of the way
+	x, y, _ = tick(s, 60, 0, 0)
+	if math.Abs(x) > 1e-6 || math.Abs(y-20) > 1e-6 {
+		t.Errorf("halfway at (%v, %v), want (0, 20)", x, y)
+	}
+
+	// past the move, the zero length one is skipped straight away and the
+	// handback starts from the last waypoint
+	x, y, _ = tick(s, 31, 0, 0)
+	if x > 40 || x < 39 || y > 80 || y < 79 {
+		t.Errorf("after the jump at (%v, %v), want just off (40, 80)", x, y)
+	}
+}
+
+func TestQueue(t *testing.T) {
+	first := &Path{
+		Name:      "first",
+		Waypoints: []Waypoint{{X: 0, Y: 0}, {X: 10, Y: 0, Duration: 0.5}},
+		Easing:    Linear,
+		Handback:  0,
+	}
+	second := &Path{
+		Name:      "second",
+		Waypoints: []Waypoint{{X: 500, Y: 500}, {X: 600, Y: 500, Duration: 0.5}},
+		Easing:    Linear,
+		Handback:  0,
+	}
+
+	cam := NewCamera(0, 0)
+	cam.Queue(first)
+	cam.Queue(second)
+	if !cam.Scripted() || cam.script.path != first {
+		t.Fatal("the first queued path isn't playing")
+	}
+
+	for i := 0; i < 40 && cam.script != nil && cam.script.path == first; i++ {
+		cam.Follow(0, 0, 0, 0, screenWidth, screenHeight)
+	}
+	if !cam.Scripted() || cam.script.path != second {
+		t.Fatal("the second path didn't start after the first")
+	}
+
+	for i := 0; i < 40 && cam.Scripted(); i++ {
+		cam.Follow(0, 0, 0, 0, screenWidth, screenHeight)
+	}
+	if cam.Scripted() {
+		t.Error("still scripted after both paths")
+	}
+}
@@ -0,0 +1,70 @@
+package camera
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// ShakeMode controls screen shake. Shake grows with the square of the
+// camera's trauma, so small hits barely move the screen and big ones throw it
+// around. The zero value never shakes.
+type ShakeMode struct {
+	MaxOffset float64 // pixels at full trauma
+	MaxAngle  float64 // radians at full trauma
+	Frequency float64 // how many times a second the shake changes direction, roughly
+	Decay     float64 // trauma lost per second
+	// How quickly impulses settle, per second. The distance left shrinks exponentially.
+	ImpulseDamping float64
+}
+
+// state of the shake and impulses, on top of the camera's own position
+type shake struct {
+	trauma  float64 // 0 to 1
+	time    float64 // seconds, drives the noise
+	impulse [2]float64
+
+	// what Update worked out for this tick
+	offsetX, offsetY float64
+	angle            float64
+}
+
+// AddTrauma shakes the screen harder, amount is between 0 and 1. Trauma adds
+// up to at most 1 and wears off at Shake.Decay.
+func (c *Camera) AddTrauma(amount float64) {
+	c.shake.trauma = math.Min(1, c.shake.trauma+amount)
+}
+
+// Impulse knocks the view by (dx, dy) pixels at once, which then settles back.
+func (c *Camera) Impulse(dx, dy float64) {
+	c.shake.impulse[0] += dx
+	c.shake.impulse[1] += dy
+}
+
+// Smooth noise between -1 and 1, a few sines at unrelated frequencies so it doesn't visibly repeat.
+func noise(seed, t float64) float64 {
+	return (math.Sin(t*1.0+seed) + math.Sin(t*2.3+seed*1.7)*0.5 + math.Sin(t*5.9+seed*3.1)*0.25) / 1.75
+}
+
+// Update advances the shake and impulses, once a tick.
+func (c *Camera) Update() {
+	dt := 1.0 / float64(ebiten.TPS())
+	s := &c.shake
+
+	s.time += dt
+	s.trauma = math.Max(0, s.trauma-c.Shake.Decay*dt)
+
+	if c.Shake.ImpulseDamping > 0 {
+		settle := math.Exp(-c.Shake.ImpulseDamping * dt)
+		s.impulse[0] *= settle
+		s.impulse[1] *= settle
+	} else {
+		s.impulse = [2]float64{}
+	}
+
+	amount := s.trauma * s.trauma
+	t := s.time * c.Shake.Frequency * 2 * math.Pi
+	s.offsetX = c.Shake.MaxOffset*amount*noise(0, t) + s.impulse[0]
+	s.offsetY = c.Shake.MaxOffset*amount*noise(10, t) + s.impulse[1]
+	s.angle = c.Shake.MaxAngle * amount * noise(20, t)
+}
@@ -2,4 +2,8 @@ package constants
 
 const (
 	Tilesize = 40
-)
\ No newline at end of file
+
+	// logical screen size, the world is drawn at this size and scaled to the window
+	ScreenWidth  = 320
+	ScreenHeight = 240
+)
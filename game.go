@@ -1,6 +1,7 @@
 package main
 
 import (
+	"rpg-game-go/constants"
 	"rpg-game-go/scenes"
 
 	"github.com/hajimehoshi/ebiten/v2"
@@ -52,5 +53,5 @@ func (g *Game) Draw(screen *ebiten.Image) {
 }
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
-	return 320, 240
+	return constants.ScreenWidth, constants.ScreenHeight
 }
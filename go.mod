@@ -2,7 +2,10 @@ module rpg-game-go
 
 go 1.22.1
 
-require github.com/hajimehoshi/ebiten/v2 v2.8.6
+require (
+	github.com/hajimehoshi/ebiten/v2 v2.8.6
+	github.com/klauspost/compress v1.18.0
+)
 
 require (
 	github.com/ebitengine/gomobile v0.0.0-20240911145611-4856209ac325 // indirect
@@ -0,0 +1,117 @@
+package objects
+
+import (
+	"image"
+	"math"
+	"rpg-game-go/properties"
+)
+
+type PointJSON struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// A single object from a Tiled "objectgroup". The shape is a rectangle unless
+// one of Ellipse, Point, Polygon or Polyline is set.
+type ObjectJSON struct {
+	Id       int         `json:"id"`
+	Name     string      `json:"name"`
+	Type     string      `json:"type"`
+	Class    string      `json:"class"`
+	X        float64     `json:"x"`
+	Y        float64     `json:"y"`
+	Width    float64     `json:"width"`
+	Height   float64     `json:"height"`
+	Rotation float64     `json:"rotation"` // degrees, clockwise around (X, Y)
+	Visible  bool        `json:"visible"`
+	Ellipse  bool        `json:"ellipse"`
+	Point    bool        `json:"point"`
+	Polygon  []PointJSON `json:"polygon"`
+	Polyline []PointJSON `json:"polyline"`
+	Gid      int         `json:"gid"` // tile objects only, may carry flip flags
+
+	Properties properties.Properties `json:"properties"`
+}
+
+// The "objectgroup" attached to a tile in a tileset, holding its collision shapes.
+type ObjectGroupJSON struct {
+	Objects []*ObjectJSON `json:"objects"`
+}
+
+// Kind is the object's type, or its class when the type is empty. Tiled 1.9
+// saved the type of objects as "class" in .tmx files.
+func (o *ObjectJSON) Kind() string {
+	if o.Type != "" {
+		return o.Type
+	}
+	return o.Class
+}
+
+func (o *ObjectJSON) IsRectangle() bool {
+	return !o.Ellipse && !o.Point && o.Polygon == nil && o.Polyline == nil
+}
+
+// Points of the shape relative to the object's position, before rotation.
+func (o *ObjectJSON) outline() []PointJSON {
+	if o.Polygon != nil {
+		return o.Polygon
+	}
+	if o.Polyline != nil {
+		return o.Polyline
+	}
+	if o.Point {
+		return []PointJSON{{0, 0}}
+	}
+
+	// rectangles and ellipses share the same bounding box
+	return []PointJSON{
+		{0, 0},
+		{o.Width, 0},
+		{o.Width, o.Height},
+		{0, o.Height},
+	}
+}
+
+// Bounds returns the world-space bounding box of the object, taking rotation
+// into account. Points have an empty bounding box.
+func (o *ObjectJSON) Bounds() image.Rectangle {
+	if o.Point {
+		return image.Rectangle{}
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range o.worldPoints(o.outline()) {
+		minX = math.Min(minX, p.X)
+		minY = math.Min(minY, p.Y)
+		maxX = math.Max(maxX, p.X)
+		maxY = math.Max(maxY, p.Y)
+	}
+
+	rect := image.Rect(
+		int(math.Floor(minX)),
+		int(math.Floor(minY)),
+		int(math.Ceil(maxX)),
+		int(math.Ceil(maxY)),
+	)
+
+	// straight polylines have no area, give them a 1px thickness so they still collide
+	if rect.Dx() == 0 {
+		rect.Max.X += 1
+	}
+	if rect.Dy() == 0 {
+		rect.Max.Y += 1
+	}
+	return rect
+}
+
+// Colliders turns every non-point object into rectangles covering its shape,
+// see ObjectJSON.Colliders.
+func Colliders(objs []*ObjectJSON) []image.Rectangle {
+	colliders := make([]image.Rectangle, 0)
+
+	for _, obj := range objs {
+		colliders = append(colliders, obj.Colliders()...)
+	}
+	return colliders
+}
@@ -0,0 +1,148 @@
+package objects
+
+import (
+	"image"
+	"math"
+	"sort"
+)
+
+// Size of the rectangles slanted and round collision shapes are cut into, in
+// pixels. Smaller follows the shape closer but makes more colliders.
+const ShapeStep = 4
+
+// Colliders covers the object's shape with rectangles. Rectangles that are
+// straight or turned by a multiple of 90° are exact. Polygons, ellipses and
+// other rotated rectangles are cut into rows ShapeStep pixels high, and
+// polylines into pieces at most ShapeStep pixels long, so slanted walls only
+// block close to where they are drawn. Points have no colliders.
+func (o *ObjectJSON) Colliders() []image.Rectangle {
+	switch {
+	case o.Point:
+		return nil
+	case o.Polyline != nil:
+		return segmentRects(o.worldPoints(o.Polyline))
+	case o.Polygon != nil:
+		return polygonRects(o.worldPoints(o.Polygon))
+	case o.Ellipse:
+		return polygonRects(o.worldPoints(o.ellipseOutline()))
+	case math.Mod(o.Rotation, 90) != 0:
+		return polygonRects(o.worldPoints(o.outline()))
+	}
+
+	rect := o.Bounds()
+	if rect.Empty() {
+		return nil
+	}
+	return []image.Rectangle{rect}
+}
+
+// worldPoints rotates points relative to the object around its position and
+// moves them into the world.
+func (o *ObjectJSON) worldPoints(points []PointJSON) []PointJSON {
+	sin, cos := math.Sincos(o.Rotation * math.Pi / 180.0)
+
+	world := make([]PointJSON, 0, len(points))
+	for _, p := range points {
+		world = append(world, PointJSON{
+			X: o.X + p.X*cos - p.Y*sin,
+			Y: o.Y + p.X*sin + p.Y*cos,
+		})
+	}
+	return world
+}
+
+// Points around the ellipse inside the object's box, close enough together
+// that the rows cut from them stay within a pixel of the curve.
+func (o *ObjectJSON) ellipseOutline() []PointJSON {
+	const segments = 32
+
+	rx, ry := o.Width/2, o.Height/2
+	points := make([]PointJSON, 0, segments)
+	for i := 0; i < segments; i++ {
+		sin, cos := math.Sincos(2 * math.Pi * float64(i) / segments)
+		points = append(points, PointJSON{rx + rx*cos, ry + ry*sin})
+	}
+	return points
+}
+
+// rectAround is the smallest rectangle of whole pixels holding both points,
+// at least a pixel wide and high so straight lines still collide.
+func rectAround(a, b PointJSON) image.Rectangle {
+	rect := image.Rect(
+		int(math.Floor(math.Min(a.X, b.X))),
+		int(math.Floor(math.Min(a.Y, b.Y))),
+		int(math.Ceil(math.Max(a.X, b.X))),
+		int(math.Ceil(math.Max(a.Y, b.Y))),
+	)
+	if rect.Dx() == 0 {
+		rect.Max.X += 1
+	}
+	if rect.Dy() == 0 {
+		rect.Max.Y += 1
+	}
+	return rect
+}
+
+// segmentRects cuts every segment of a polyline into pieces no longer than
+// ShapeStep along either axis and covers each with a rectangle.
+func segmentRects(points []PointJSON) []image.Rectangle {
+	rects := make([]image.Rectangle, 0)
+
+	for i := 0; i+1 < len(points); i++ {
+		from, to := points[i], points[i+1]
+		dx, dy := to.X-from.X, to.Y-from.Y
+
+		pieces := max(1, int(math.Ceil(math.Max(math.Abs(dx), math.Abs(dy))/ShapeStep)))
+		for piece := 0; piece < pieces; piece++ {
+			start := float64(piece) / float64(pieces)
+			end := float64(piece+1) / float64(pieces)
+			rects = append(rects, rectAround(
+				PointJSON{from.X + dx*start, from.Y + dy*start},
+				PointJSON{from.X + dx*end, from.Y + dy*end},
+			))
+		}
+	}
+	return rects
+}
+
+// polygonRects cuts a closed polygon into rows ShapeStep pixels high. Each row
+// covers where the polygon is at the middle of the row, concave polygons can
+// have several pieces in one row.
+func polygonRects(points []PointJSON) []image.Rectangle {
+	rects := make([]image.Rectangle, 0)
+	if len(points) < 3 {
+		return rects
+	}
+
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, p := range points {
+		minY = math.Min(minY, p.Y)
+		maxY = math.Max(maxY, p.Y)
+	}
+	top, bottom := int(math.Floor(minY)), int(math.Ceil(maxY))
+
+	crossings := make([]float64, 0)
+	for y := top; y < bottom; y += ShapeStep {
+		rowBottom := min(y+ShapeStep, bottom)
+		middle := float64(y+rowBottom) / 2
+
+		// where the polygon's edges cross the middle of the row, left to right
+		crossings = crossings[:0]
+		for i, p := range points {
+			q := points[(i+1)%len(points)]
+			if (p.Y <= middle) == (q.Y <= middle) {
+				continue
+			}
+			crossings = append(crossings, p.X+(middle-p.Y)*(q.X-p.X)/(q.Y-p.Y))
+		}
+		sort.Float64s(crossings)
+
+		// inside between every other pair of crossings
+		for i := 0; i+1 < len(crossings); i += 2 {
+			left := int(math.Floor(crossings[i]))
+			right := max(int(math.Ceil(crossings[i+1])), left+1)
+			rects = append(rects, image.Rect(left, y, right, rowBottom))
+		}
+	}
+	return rects
+}
@@ -0,0 +1,152 @@
+package objects
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func covered(rects []image.Rectangle, x, y int) bool {
+	for _, rect := range rects {
+		if image.Pt(x, y).In(rect) {
+			return true
+		}
+	}
+	return false
+}
+
+func area(rects []image.Rectangle) int {
+	total := 0
+	for _, rect := range rects {
+		total += rect.Dx() * rect.Dy()
+	}
+	return total
+}
+
+func TestCollidersRectangles(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  ObjectJSON
+		want []image.Rectangle
+	}{
+		{"rectangle", ObjectJSON{X: 10, Y: 20, Width: 30, Height: 40}, []image.Rectangle{image.Rect(10, 20, 40, 60)}},
+		{"fractional", ObjectJSON{X: 10.5, Y: 20, Width: 30, Height: 40.2}, []image.Rectangle{image.Rect(10, 20, 41, 61)}},
+		// turned clockwise around its top left
+		{"rotated 90", ObjectJSON{X: 100, Y: 100, Width: 30, Height: 10, Rotation: 90}, []image.Rectangle{image.Rect(90, 100, 100, 130)}},
+		{"rotated -180", ObjectJSON{X: 100, Y: 100, Width: 30, Height: 10, Rotation: -180}, []image.Rectangle{image.Rect(70, 90, 100, 100)}},
+		{"point", ObjectJSON{X: 10, Y: 20, Point: true}, nil},
+	}
+
+	for _, test := range tests {
+		got := test.obj.Colliders()
+		if len(got) != len(test.want) {
+			t.Errorf("%s: Colliders = %v, want %v", test.name, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%s: Colliders = %v, want %v", test.name, got, test.want)
+			}
+		}
+	}
+}
+
+func TestCollidersDiagonalPolyline(t *testing.T) {
+	// a wall from (0, 0) to (100, 100)
+	obj := ObjectJSON{X: 0, Y: 0, Polyline: []PointJSON{{0, 0}, {100, 100}}}
+	rects := obj.Colliders()
+
+	for _, p := range []image.Point{{0, 0}, {50, 50}, {99, 99}, {20, 21}} {
+		if !covered(rects, p.X, p.Y) {
+			t.Errorf("%v on the line isn't covered", p)
+		}
+	}
+	// the bounding box would block these
+	for _, p := range []image.Point{{90, 10}, {10, 90}, {50, 40}, {40, 60}} {
+		if covered(rects, p.X, p.Y) {
+			t.Errorf("%v away from the line is covered", p)
+		}
+	}
+	if area(rects) > 100*ShapeStep*2 {
+		t.Errorf("covers %d pixels, far more than the line", area(rects))
+	}
+}
+
+func TestCollidersStraightPolyline(t *testing.T) {
+	obj := ObjectJSON{X: 10, Y: 10, Polyline: []PointJSON{{0, 0}, {50, 0}, {50, 20}}}
+	rects := obj.Colliders()
+
+	for _, p := range []image.Point{{10, 10}, {35, 10}, {60, 10}, {60, 29}} {
+		if !covered(rects, p.X, p.Y) {
+			t.Errorf("%v on the line isn't covered", p)
+		}
+	}
+	if covered(rects, 30, 20) {
+		t.Error("inside the corner is covered")
+	}
+}
+
+func TestCollidersTriangle(t *testing.T) {
+	// a cliff with its slope from the top left to the bottom right
+	obj := ObjectJSON{X: 100, Y: 100, Polygon: []PointJSON{{0, 0}, {64, 64}, {0, 64}}}
+	rects := obj.Colliders()
+
+	for _, p := range []image.Point{{101, 110}, {110, 160}, {150, 162}, {120, 140}} {
+		if !covered(rects, p.X, p.Y) {
+			t.Errorf("%v inside the triangle isn't covered", p)
+		}
+	}
+	for _, p := range []image.Point{{160, 104}, {140, 110}, {130, 120}, {99, 120}, {120, 164}} {
+		if covered(rects, p.X, p.Y) {
+			t.Errorf("%v outside the triangle is covered", p)
+		}
+	}
+}
+
+func TestCollidersConcavePolygon(t *testing.T) {
+	// a U, open at the top
+	obj := ObjectJSON{Polygon: []PointJSON{{0, 0}, {10, 0}, {10, 30}, {30, 30}, {30, 0}, {40, 0}, {40, 40}, {0, 40}}}
+	rects := obj.Colliders()
+
+	for _, p := range []image.Point{{5, 5}, {35, 5}, {20, 35}} {
+		if !covered(rects, p.X, p.Y) {
+			t.Errorf("%v inside the U isn't covered", p)
+		}
+	}
+	if covered(rects, 20, 10) {
+		t.Error("the gap of the U is covered")
+	}
+}
+
+func TestCollidersRotatedRectangle(t *testing.T) {
+	// a 40x10 plank turned 45° around its top left
+	obj := ObjectJSON{X: 0, Y: 0, Width: 40, Height: 10, Rotation: 45}
+	rects := obj.Colliders()
+
+	// middle of the plank
+	cx, cy := 20*math.Cos(math.Pi/4)-5*math.Sin(math.Pi/4), 20*math.Sin(math.Pi/4)+5*math.Cos(math.Pi/4)
+	if !covered(rects, int(cx), int(cy)) {
+		t.Errorf("middle of the plank (%v, %v) isn't covered", cx, cy)
+	}
+	// corners of the bounding box
+	bounds := obj.Bounds()
+	for _, p := range []image.Point{{bounds.Max.X - 1, bounds.Min.Y}, {bounds.Min.X, bounds.Max.Y - 1}} {
+		if covered(rects, p.X, p.Y) {
+			t.Errorf("corner %v of the bounding box is covered", p)
+		}
+	}
+}
+
+func TestCollidersEllipse(t *testing.T) {
+	obj := ObjectJSON{X: 0, Y: 0, Width: 40, Height: 40, Ellipse: true}
+	rects := obj.Colliders()
+
+	if !covered(rects, 20, 20) || !covered(rects, 2, 20) || !covered(rects, 20, 37) {
+		t.Error("inside the circle isn't covered")
+	}
+	for _, p := range []image.Point{{0, 0}, {39, 0}, {0, 39}, {39, 39}} {
+		if covered(rects, p.X, p.Y) {
+			t.Errorf("corner %v is covered", p)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package objects
+
+import (
+	"rpg-game-go/properties"
+	"strconv"
+	"strings"
+)
+
+// XML (.tmx/.tsx) versions of the object types. They are converted to the JSON
+// types on load so the rest of the game only deals with one model.
+
+type ShapeXML struct {
+	Points string `xml:"points,attr"`
+}
+
+type ObjectXML struct {
+	Id         int                      `xml:"id,attr"`
+	Name       string                   `xml:"name,attr"`
+	Type       string                   `xml:"type,attr"`
+	Class      string                   `xml:"class,attr"`
+	X          float64                  `xml:"x,attr"`
+	Y          float64                  `xml:"y,attr"`
+	Width      float64                  `xml:"width,attr"`
+	Height     float64                  `xml:"height,attr"`
+	Rotation   float64                  `xml:"rotation,attr"`
+	Visible    string                   `xml:"visible,attr"`
+	Ellipse    *struct{}                `xml:"ellipse"`
+	Point      *struct{}                `xml:"point"`
+	Polygon    *ShapeXML                `xml:"polygon"`
+	Polyline   *ShapeXML                `xml:"polyline"`
+	Gid        uint32                   `xml:"gid,attr"` // tile objects only, may carry flip flags
+	Properties []properties.PropertyXML `xml:"properties>property"`
+}
+
+type ObjectGroupXML struct {
+	Objects []*ObjectXML `xml:"object"`
+}
+
+// "x1,y1 x2,y2 ..." as used by polygon and polyline.
+func parsePoints(points string) []PointJSON {
+	parsed := make([]PointJSON, 0)
+
+	for _, pair := range strings.Fields(points) {
+		x, y, found := strings.Cut(pair, ",")
+		if !found {
+			continue
+		}
+
+		px, errX := strconv.ParseFloat(x, 64)
+		py, errY := strconv.ParseFloat(y, 64)
+		if errX != nil || errY != nil {
+			continue
+		}
+		parsed = append(parsed, PointJSON{px, py})
+	}
+	return parsed
+}
+
+func (o *ObjectXML) JSON() *ObjectJSON {
+	obj := &ObjectJSON{
+		Id:       o.Id,
+		Name:     o.Name,
+		Type:     o.Type,
+		Class:    o.Class,
+		X:        o.X,
+		Y:        o.Y,
+		Width:    o.Width,
+		Height:   o.Height,
+		Rotation: o.Rotation,
+		// the attribute is only written when the object is hidden
+		Visible:    o.Visible != "0",
+		Ellipse:    o.Ellipse != nil,
+		Point:      o.Point != nil,
+		Gid:        int(o.Gid),
+		Properties: properties.FromXML(o.Properties),
+	}
+
+	if o.Polygon != nil {
+		obj.Polygon = parsePoints(o.Polygon.Points)
+	}
+	if o.Polyline != nil {
+		obj.Polyline = parsePoints(o.Polyline.Points)
+	}
+	return obj
+}
+
+func (g *ObjectGroupXML) JSON() *ObjectGroupJSON {
+	objs := make([]*ObjectJSON, 0, len(g.Objects))
+	for _, obj := range g.Objects {
+		objs = append(objs, obj.JSON())
+	}
+
+	return &ObjectGroupJSON{
+		Objects: objs,
+	}
+}
@@ -0,0 +1,188 @@
+package properties
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Property types Tiled can save.
+const (
+	String = "string"
+	Int    = "int"
+	Float  = "float"
+	Bool   = "bool"
+	Color  = "color"
+	File   = "file"
+	Object = "object"
+	Class  = "class"
+)
+
+// A custom property set in Tiled on a map, layer, tile or object.
+type PropertyJSON struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"`         // empty means string
+	PropertyType string `json:"propertytype"` // name of the custom class or enum
+	Value        any    `json:"value"`
+}
+
+// Properties of one map, layer, tile or object. The getters return fallback
+// when the property isn't set or holds a value of another type.
+type Properties []PropertyJSON
+
+// Get returns the first property with the given name.
+func (p Properties) Get(name string) (*PropertyJSON, bool) {
+	for i := range p {
+		if p[i].Name == name {
+			return &p[i], true
+		}
+	}
+	return nil, false
+}
+
+func (p Properties) Has(name string) bool {
+	_, ok := p.Get(name)
+	return ok
+}
+
+// String returns string, file and color properties, as well as enums stored as strings.
+func (p Properties) String(name, fallback string) string {
+	prop, ok := p.Get(name)
+	if !ok {
+		return fallback
+	}
+
+	s, ok := prop.Value.(string)
+	if !ok {
+		return fallback
+	}
+	return s
+}
+
+func (p Properties) Int(name string, fallback int) int {
+	prop, ok := p.Get(name)
+	if !ok {
+		return fallback
+	}
+
+	// encoding/json decodes every number as a float64
+	number, ok := prop.Value.(float64)
+	if !ok {
+		return fallback
+	}
+	return int(number)
+}
+
+func (p Properties) Float(name string, fallback float64) float64 {
+	prop, ok := p.Get(name)
+	if !ok {
+		return fallback
+	}
+
+	number, ok := prop.Value.(float64)
+	if !ok {
+		return fallback
+	}
+	return number
+}
+
+func (p Properties) Bool(name string, fallback bool) bool {
+	prop, ok := p.Get(name)
+	if !ok {
+		return fallback
+	}
+
+	b, ok := prop.Value.(bool)
+	if !ok {
+		return fallback
+	}
+	return b
+}
+
+// Color returns a color property. Tiled saves an unset color as "".
+func (p Properties) Color(name string, fallback color.NRGBA) color.NRGBA {
+	c, err := ParseColor(p.String(name, ""))
+	if err != nil {
+		return fallback
+	}
+	return c
+}
+
+// File returns a file property, relative to the file it was set in.
+func (p Properties) File(name, fallback string) string {
+	return p.String(name, fallback)
+}
+
+// Object returns the id of the object a property points at, 0 if it points at nothing.
+func (p Properties) Object(name string) int {
+	return p.Int(name, 0)
+}
+
+// Class returns the members of a class property as properties of their own.
+// Members left at their default aren't saved, so they are missing here too.
+func (p Properties) Class(name string) Properties {
+	prop, ok := p.Get(name)
+	if !ok {
+		return nil
+	}
+
+	members, ok := prop.Value.(map[string]any)
+	if !ok {
+		return nil
+	}
+	return fromMap(members)
+}
+
+// Class members are saved as a plain JSON object, so their types are guessed
+// from the decoded values.
+func fromMap(members map[string]any) Properties {
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	props := make(Properties, 0, len(members))
+	for _, name := range names {
+		value := members[name]
+
+		kind := String
+		switch value.(type) {
+		case float64:
+			kind = Float
+		case bool:
+			kind = Bool
+		case map[string]any:
+			kind = Class
+		}
+
+		props = append(props, PropertyJSON{
+			Name:  name,
+			Type:  kind,
+			Value: value,
+		})
+	}
+	return props
+}
+
+// ParseColor reads a Tiled color, "#rrggbb" or "#aarrggbb".
+func ParseColor(s string) (color.NRGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || (len(hex) != 6 && len(hex) != 8) {
+		return color.NRGBA{}, fmt.Errorf("properties: bad color %q", s)
+	}
+	if len(hex) == 6 {
+		value |= 0xff000000
+	}
+
+	return color.NRGBA{
+		R: uint8(value >> 16),
+		G: uint8(value >> 8),
+		B: uint8(value),
+		A: uint8(value >> 24),
+	}, nil
+}
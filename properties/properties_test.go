@@ -0,0 +1,162 @@
+package properties
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+// Properties the way Tiled saves them in a .tmj.
+const propertiesJSON = `[
+	{"name": "title", "type": "string", "value": "Spawn"},
+	{"name": "health", "type": "int", "value": 12},
+	{"name": "speed", "type": "float", "value": 1.5},
+	{"name": "hostile", "type": "bool", "value": true},
+	{"name": "tint", "type": "color", "value": "#80ff0000"},
+	{"name": "unset", "type": "color", "value": ""},
+	{"name": "music", "type": "file", "value": "../audio/spawn.ogg"},
+	{"name": "target", "type": "object", "value": 7},
+	{"name": "loot", "type": "class", "propertytype": "Loot", "value": {"gold": 3, "rare": false, "name": "chest"}},
+	{"name": "health", "type": "int", "value": 99}
+]`
+
+func decodeProperties(t *testing.T) Properties {
+	t.Helper()
+
+	var props Properties
+	err := json.Unmarshal([]byte(propertiesJSON), &props)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return props
+}
+
+func TestGetters(t *testing.T) {
+	props := decodeProperties(t)
+
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"String", props.String("title", "none"), "Spawn"},
+		{"String missing", props.String("missing", "none"), "none"},
+		{"String of an int", props.String("health", "none"), "none"},
+		// the first one wins
+		{"Int", props.Int("health", -1), 12},
+		{"Int missing", props.Int("missing", -1), -1},
+		{"Int of a string", props.Int("title", -1), -1},
+		{"Int of a float", props.Int("speed", -1), 1},
+		{"Float", props.Float("speed", -1), 1.5},
+		{"Float of an int", props.Float("health", -1), 12.0},
+		{"Float of a bool", props.Float("hostile", -1), -1.0},
+		{"Bool", props.Bool("hostile", false), true},
+		{"Bool missing", props.Bool("missing", true), true},
+		{"Bool of a string", props.Bool("title", true), true},
+		{"Color", props.Color("tint", color.NRGBA{}), color.NRGBA{R: 0xff, A: 0x80}},
+		{"Color unset", props.Color("unset", color.NRGBA{B: 1}), color.NRGBA{B: 1}},
+		{"Color of a string", props.Color("title", color.NRGBA{B: 1}), color.NRGBA{B: 1}},
+		{"File", props.File("music", ""), "../audio/spawn.ogg"},
+		{"Object", props.Object("target"), 7},
+		{"Object missing", props.Object("missing"), 0},
+		{"Has", props.Has("speed"), true},
+		{"Has missing", props.Has("missing"), false},
+	}
+
+	for _, test := range tests {
+		if !reflect.DeepEqual(test.got, test.want) {
+			t.Errorf("%s = %v, want %v", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestClass(t *testing.T) {
+	props := decodeProperties(t)
+
+	loot := props.Class("loot")
+	if len(loot) != 3 {
+		t.Fatalf("Class has %d members, want 3", len(loot))
+	}
+	// sorted by name
+	for i, name := range []string{"gold", "name", "rare"} {
+		if loot[i].Name != name {
+			t.Errorf("member %d is %q, want %q", i, loot[i].Name, name)
+		}
+	}
+	if loot.Int("gold", 0) != 3 || loot.String("name", "") != "chest" || loot.Bool("rare", true) {
+		t.Errorf("Class members = %+v", loot)
+	}
+
+	if props.Class("missing") != nil {
+		t.Error("Class of a missing property isn't nil")
+	}
+	if props.Class("title") != nil {
+		t.Error("Class of a string property isn't nil")
+	}
+}
+
+func TestFromXML(t *testing.T) {
+	const tmx = `<properties>
+		<property name="health" type="int" value="12"/>
+		<property name="speed" type="float" value="1.5"/>
+		<property name="hostile" type="bool" value="true"/>
+		<property name="title" value="Spawn"/>
+		<property name="notes">first line
+second line</property>
+		<property name="loot" type="class" propertytype="Loot">
+			<properties>
+				<property name="gold" type="int" value="3"/>
+			</properties>
+		</property>
+	</properties>`
+
+	var parsed struct {
+		Properties []PropertyXML `xml:"property"`
+	}
+	err := xml.Unmarshal([]byte(tmx), &parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	props := FromXML(parsed.Properties)
+
+	if props.Int("health", 0) != 12 || props.Float("speed", 0) != 1.5 || !props.Bool("hostile", false) || props.String("title", "") != "Spawn" {
+		t.Errorf("FromXML = %+v", props)
+	}
+	if props.String("notes", "") != "first line\nsecond line" {
+		t.Errorf("multiline string = %q", props.String("notes", ""))
+	}
+	if props.Class("loot").Int("gold", 0) != 3 {
+		t.Errorf("class member gold = %d, want 3", props.Class("loot").Int("gold", 0))
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		s    string
+		want color.NRGBA
+	}{
+		{"#ff8000", color.NRGBA{R: 0xff, G: 0x80, B: 0x00, A: 0xff}},
+		{"#80ff8000", color.NRGBA{R: 0xff, G: 0x80, B: 0x00, A: 0x80}},
+		{"#00000000", color.NRGBA{}},
+		{"ff8000", color.NRGBA{R: 0xff, G: 0x80, B: 0x00, A: 0xff}},
+		{"#FF8000", color.NRGBA{R: 0xff, G: 0x80, B: 0x00, A: 0xff}},
+	}
+
+	for _, test := range tests {
+		got, err := ParseColor(test.s)
+		if err != nil || got != test.want {
+			t.Errorf("ParseColor(%q) = %v, %v, want %v", test.s, got, err, test.want)
+		}
+	}
+}
+
+func TestParseColorErrors(t *testing.T) {
+	for _, s := range []string{"", "#", "#fff", "#ff80001", "#ff8000ff00", "#gg8000", "#-f8000", "red"} {
+		c, err := ParseColor(s)
+		if err == nil {
+			t.Errorf("ParseColor(%q) = %v, want an error", s, c)
+		}
+	}
+}
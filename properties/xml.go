@@ -0,0 +1,58 @@
+package properties
+
+import "strconv"
+
+// XML (.tmx/.tsx) version of a property, converted to PropertyJSON on load.
+type PropertyXML struct {
+	Name         string        `xml:"name,attr"`
+	Type         string        `xml:"type,attr"`
+	PropertyType string        `xml:"propertytype,attr"`
+	Value        string        `xml:"value,attr"`
+	Text         string        `xml:",chardata"`           // multiline strings are stored as the element text
+	Members      []PropertyXML `xml:"properties>property"` // members of a class
+}
+
+func (p *PropertyXML) JSON() PropertyJSON {
+	value := p.Value
+	if value == "" {
+		value = p.Text
+	}
+
+	prop := PropertyJSON{
+		Name:         p.Name,
+		Type:         p.Type,
+		PropertyType: p.PropertyType,
+		Value:        value,
+	}
+
+	// match what encoding/json would have decoded
+	switch p.Type {
+	case Int, Float, Object:
+		number, err := strconv.ParseFloat(value, 64)
+		if err == nil {
+			prop.Value = number
+		}
+	case Bool:
+		prop.Value = value == "true"
+	case Class:
+		members := make(map[string]any, len(p.Members))
+		for i := range p.Members {
+			member := p.Members[i].JSON()
+			members[member.Name] = member.Value
+		}
+		prop.Value = members
+	}
+	return prop
+}
+
+func FromXML(props []PropertyXML) Properties {
+	if props == nil {
+		return nil
+	}
+
+	converted := make(Properties, 0, len(props))
+	for i := range props {
+		converted = append(converted, props[i].JSON())
+	}
+	return converted
+}
@@ -0,0 +1,42 @@
+package render
+
+import (
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type item struct {
+	footY float64
+	draw  func(screen *ebiten.Image)
+}
+
+// Queue draws sprites and tall tiles back to front by the Y of their feet,
+// so whatever stands lower on screen covers what stands behind it.
+type Queue struct {
+	items []item
+}
+
+func NewQueue() *Queue {
+	return &Queue{
+		items: make([]item, 0),
+	}
+}
+
+// Push queues draw to run at depth footY, the bottom edge of the thing in world pixels.
+func (q *Queue) Push(footY float64, draw func(screen *ebiten.Image)) {
+	q.items = append(q.items, item{footY, draw})
+}
+
+// Draw runs everything queued, lowest footY first, and empties the queue.
+// Items at the same depth keep the order they were pushed in.
+func (q *Queue) Draw(screen *ebiten.Image) {
+	sort.SliceStable(q.items, func(i, j int) bool {
+		return q.items[i].footY < q.items[j].footY
+	})
+
+	for _, it := range q.items {
+		it.draw(screen)
+	}
+	q.items = q.items[:0]
+}
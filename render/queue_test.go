@@ -0,0 +1,48 @@
+package render
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestQueueDraw(t *testing.T) {
+	q := NewQueue()
+	drawn := make([]string, 0)
+	push := func(footY float64, name string) {
+		q.Push(footY, func(screen *ebiten.Image) {
+			drawn = append(drawn, name)
+		})
+	}
+
+	push(40, "tree")
+	push(10, "house")
+	push(25, "player")
+	push(-5, "cloud")
+	// same depth as the player, drawn after it because it was pushed later
+	push(25, "sword")
+	push(10.5, "enemy")
+
+	q.Draw(nil)
+
+	want := []string{"cloud", "house", "enemy", "player", "sword", "tree"}
+	if !reflect.DeepEqual(drawn, want) {
+		t.Errorf("drawn %v, want %v", drawn, want)
+	}
+}
+
+func TestQueueDrawEmpties(t *testing.T) {
+	q := NewQueue()
+	count := 0
+	q.Push(1, func(screen *ebiten.Image) {
+		count++
+	})
+
+	q.Draw(nil)
+	q.Draw(nil)
+
+	if count != 1 {
+		t.Errorf("drawn %d times, want once", count)
+	}
+}
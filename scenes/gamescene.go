@@ -5,14 +5,16 @@ import (
 	"image"
 	"image/color"
 	"log"
+	"math"
 	"rpg-game-go/animations"
 	"rpg-game-go/camera"
-	"rpg-game-go/components"
 	"rpg-game-go/constants"
 	"rpg-game-go/entities"
+	"rpg-game-go/render"
+	"rpg-game-go/spawn"
 	"rpg-game-go/spritesheet"
 	"rpg-game-go/tilemap"
-	"rpg-game-go/tileset"
+	"rpg-game-go/world"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
@@ -61,7 +63,23 @@ type GameScene struct {
 	enemies   []*entities.Enemy
 	potions   []*entities.Potion
 	colliders []image.Rectangle
-	tilesets  []tileset.Tileset
+	// the first mapColliders colliders are the map's, the rest come with the
+	// chunks of an infinite map that are streamed in
+	mapColliders int
+	gids         *tilemap.GidResolver
+	mapBounds    image.Rectangle
+
+	mapRenderer *tilemap.Renderer
+	mapPath     string
+	world       *world.WorldJSON
+	portals     []world.Portal
+	inPortal    bool
+	spawnAssets spawn.Assets
+	culler      *camera.Culler
+	debug       bool // F3 shows what the culler drew and skipped
+	renderQueue *render.Queue
+
+	cutscenesPlayed map[string]struct{} // map path and camera path name
 
 	animationFrame int
 	loaded         bool
@@ -77,6 +95,9 @@ func NewGameScene() *GameScene {
 		tilemapImg:        nil,
 		cam:               nil,
 		colliders:         make([]image.Rectangle, 0),
+		culler:            camera.NewCuller(),
+		renderQueue:       render.NewQueue(),
+		cutscenesPlayed:   make(map[string]struct{}),
 		loaded:            false,
 	}
 }
@@ -84,83 +105,12 @@ func NewGameScene() *GameScene {
 func (g *GameScene) Draw(screen *ebiten.Image) {
 	screen.Fill(color.RGBA{120, 180, 255, 255})
 
-	op := &ebiten.DrawImageOptions{}
+	g.culler.Reset(g.cam.View(constants.ScreenWidth, constants.ScreenHeight))
 
-	// loop over the layers
-	// tilesetColumns := g.tilemapImg.Bounds().Dx() / 16 // Number of tiles per row in tileset
-	for layerIndex, layer := range g.tilemapJSON.Layers {
-		for index, id := range layer.Data {
-			if id == 0 {
-				continue
-			}
-
-			x := (index % layer.Width) * 16 // tile position x
-			y := (index / layer.Width) * 16 // tile position y
-
-			img := g.tilesets[layerIndex].Img(id)
-
-			// *********** TODO: fix the yoffset issue. This is just a temporary fix.
-			// Calculate Y offset (adjust based on image height)
-			tileHeight := img.Bounds().Dy() // Get actual height
-			yOffset := tileHeight - 16      // Adjust if needed
-
-			op.GeoM.Translate(float64(x), float64(y-yOffset)) // Shift up by yOffset
-			// ***********
-
-			// op.GeoM.Translate(float64(x), float64(y))
-			op.GeoM.Translate(g.cam.X, g.cam.Y)
-
-			// fmt.Printf("Drawing tile ID %d at X=%d, Y=%d\n", id, x, y)
-			screen.DrawImage(img, op)
-
-			// srcX := (id - 1) % tilesetColumns * 16
-			// srcY := (id - 1) / tilesetColumns * 16
-
-			// screen.DrawImage(
-			// 	g.tilemapImg.SubImage(image.Rect(srcX, srcY, srcX+600, srcY+600)).(*ebiten.Image),
-			// 	op,
-			// )
-
-			op.GeoM.Reset()
-		}
-	}
-
-	// Scale factors (reduce size)
-	scaleX := 0.3 // Shrinks width to 50%
-	scaleY := 0.3 // Shrinks height to 50%
-
-	// Apply scaling
-	op.GeoM.Scale(scaleX, scaleY)
-
-	// Track positions
-	op.GeoM.Translate(g.player.X, g.player.Y)
-	op.GeoM.Translate(g.cam.X, g.cam.Y)
-
-	activeAnimation := g.player.ActiveAnimation(
-		int(g.player.Dx),
-		int(g.player.Dy),
-	)
-
-	playerFrame := 0
-	if g.player.CombatComp.Attacking() {
-		playerFrame = g.player.CombatAnimation(entities.MouseLeftClick).Frame()
-	} else if activeAnimation != nil {
-		playerFrame = activeAnimation.Frame()
-	}
-
-	// Draw our player
-	screen.DrawImage(
-		g.player.Img.SubImage(
-			// image.Rect(0, 0, 150, 150),
-			g.playerSpriteSheet.Rect(playerFrame),
-		).(*ebiten.Image),
-		op,
-	)
-
-	op.GeoM.Reset()
-
-	// Draw enemies (goblins)
-	for _, enemy := range g.enemies {
+	// Sprites are queued, the map renderer draws them in depth order with the buildings
+	_, playerFootY := spriteRect(g.player.Sprite, g.playerSpriteSheet.TileWidth, g.playerSpriteSheet.TileHeight, g.spawnAssets.Player.FootY, 0.3)
+	g.renderQueue.Push(playerFootY, func(screen *ebiten.Image) {
+		op := &ebiten.DrawImageOptions{}
 
 		// Scale factors (reduce size)
 		scaleX := 0.3 // Shrinks width to 50%
@@ -169,77 +119,157 @@ func (g *GameScene) Draw(screen *ebiten.Image) {
 		// Apply scaling
 		op.GeoM.Scale(scaleX, scaleY)
 
-		op.GeoM.Translate(enemy.X, enemy.Y)
-		op.GeoM.Translate(g.cam.X, g.cam.Y)
+		// Track positions
+		op.GeoM.Translate(g.player.X, g.player.Y)
+		op.GeoM.Concat(g.cam.GeoM(constants.ScreenWidth, constants.ScreenHeight))
 
-		activeAnimation := enemy.ActiveAnimation(
-			int(enemy.Dx),
-			int(enemy.Dy),
+		activeAnimation := g.player.ActiveAnimation(
+			int(g.player.Dx),
+			int(g.player.Dy),
 		)
 
-		enemyFrame := 0
-		if activeAnimation != nil {
-			enemyFrame = activeAnimation.Frame()
+		playerFrame := 0
+		if g.player.CombatComp.Attacking() {
+			playerFrame = g.player.CombatAnimation(entities.MouseLeftClick).Frame()
+		} else if activeAnimation != nil {
+			playerFrame = activeAnimation.Frame()
 		}
 
+		// Draw our player
 		screen.DrawImage(
-			enemy.Img.SubImage(
+			g.player.Img.SubImage(
 				// image.Rect(0, 0, 150, 150),
-				g.enemySpriteSheet.Rect(enemyFrame),
+				g.playerSpriteSheet.Rect(playerFrame),
 			).(*ebiten.Image),
 			op,
 		)
+	})
+
+	// Draw enemies (goblins)
+	for _, enemy := range g.enemies {
+		rect, footY := spriteRect(enemy.Sprite, g.enemySpriteSheet.TileWidth, g.enemySpriteSheet.TileHeight, g.spawnAssets.Enemy.FootY, 0.3)
+		if !g.culler.Visible(rect) {
+			continue
+		}
+
+		g.renderQueue.Push(footY, func(screen *ebiten.Image) {
+			op := &ebiten.DrawImageOptions{}
+
+			// Scale factors (reduce size)
+			scaleX := 0.3 // Shrinks width to 50%
+			scaleY := 0.3 // Shrinks height to 50%
+
+			// Apply scaling
+			op.GeoM.Scale(scaleX, scaleY)
 
-		op.GeoM.Reset()
+			op.GeoM.Translate(enemy.X, enemy.Y)
+			op.GeoM.Concat(g.cam.GeoM(constants.ScreenWidth, constants.ScreenHeight))
 
+			activeAnimation := enemy.ActiveAnimation(
+				int(enemy.Dx),
+				int(enemy.Dy),
+			)
+
+			enemyFrame := 0
+			if activeAnimation != nil {
+				enemyFrame = activeAnimation.Frame()
+			}
+
+			screen.DrawImage(
+				enemy.Img.SubImage(
+					// image.Rect(0, 0, 150, 150),
+					g.enemySpriteSheet.Rect(enemyFrame),
+				).(*ebiten.Image),
+				op,
+			)
+		})
 	}
 
 	// Draw potions
 	for _, enemy := range g.potions {
+		// a single image, lying on the ground with its bottom edge
+		size := enemy.Img.Bounds().Size()
+		rect, footY := spriteRect(enemy.Sprite, size.X, size.Y, size.Y, 0.3)
+		if !g.culler.Visible(rect) {
+			continue
+		}
 
-		// Scale factors (reduce size)
-		scaleX := 0.3 // Shrinks width to 50%
-		scaleY := 0.3 // Shrinks height to 50%
-
-		// Apply scaling
-		op.GeoM.Scale(scaleX, scaleY)
+		g.renderQueue.Push(footY, func(screen *ebiten.Image) {
+			op := &ebiten.DrawImageOptions{}
 
-		op.GeoM.Translate(enemy.X, enemy.Y)
-		op.GeoM.Translate(g.cam.X, g.cam.Y)
+			// Scale factors (reduce size)
+			scaleX := 0.3 // Shrinks width to 50%
+			scaleY := 0.3 // Shrinks height to 50%
 
-		screen.DrawImage(
-			enemy.Img.SubImage(
-				image.Rect(0, 0, 150, 150),
-			).(*ebiten.Image),
-			op,
-		)
+			// Apply scaling
+			op.GeoM.Scale(scaleX, scaleY)
 
-		op.GeoM.Reset()
+			op.GeoM.Translate(enemy.X, enemy.Y)
+			op.GeoM.Concat(g.cam.GeoM(constants.ScreenWidth, constants.ScreenHeight))
 
+			screen.DrawImage(enemy.Img, op)
+		})
 	}
 
+	g.mapRenderer.Draw(screen, g.cam, g.culler, g.renderQueue)
+
 	for _, collider := range g.colliders {
-		vector.StrokeRect(
-			screen,
-			float32(collider.Min.X)+float32(g.cam.X),
-			float32(collider.Min.Y)+float32(g.cam.Y),
-			float32(collider.Dx()),
-			float32(collider.Dy()),
-			1.0, color.RGBA{255, 0, 0, 255}, true,
-		)
+		g.strokeWorldRect(screen, collider, color.RGBA{255, 0, 0, 255})
+	}
+
+	if g.debug {
+		ebitenutil.DebugPrint(screen, g.culler.String())
 	}
 }
 
+// knockback is how far a hit knocks the view along an axis, away from the
+// attacker, d being the player's position minus the attacker's. Nothing if
+// they are lined up on that axis.
+func knockback(d float64) float64 {
+	if d == 0 {
+		return 0
+	}
+	return math.Copysign(3, d)
+}
+
+// strokeWorldRect outlines a rectangle given in world pixels, turned and zoomed with the camera.
+func (g *GameScene) strokeWorldRect(screen *ebiten.Image, rect image.Rectangle, clr color.Color) {
+	corners := [4][2]float64{
+		{float64(rect.Min.X), float64(rect.Min.Y)},
+		{float64(rect.Max.X), float64(rect.Min.Y)},
+		{float64(rect.Max.X), float64(rect.Max.Y)},
+		{float64(rect.Min.X), float64(rect.Max.Y)},
+	}
+
+	for i, from := range corners {
+		to := corners[(i+1)%len(corners)]
+		x0, y0 := g.cam.WorldToScreen(from[0], from[1], constants.ScreenWidth, constants.ScreenHeight)
+		x1, y1 := g.cam.WorldToScreen(to[0], to[1], constants.ScreenWidth, constants.ScreenHeight)
+		vector.StrokeLine(screen, float32(x0), float32(y0), float32(x1), float32(y1), 1.0, clr, true)
+	}
+}
+
+// spriteRect is the area a sprite covers in the world when its frames are w
+// by h pixels and drawn at scale, and the world Y of its feet, footY pixels
+// down a frame, for sorting it into the render queue.
+func spriteRect(sprite *entities.Sprite, w, h, footY int, scale float64) (image.Rectangle, float64) {
+	rect := image.Rect(
+		int(sprite.X),
+		int(sprite.Y),
+		int(sprite.X+float64(w)*scale),
+		int(sprite.Y+float64(h)*scale),
+	)
+	return rect, sprite.Y + float64(footY)*scale
+}
+
 func (g *GameScene) FirstLoad() {
-	playerImg, _, err := ebitenutil.NewImageFromFile("assets/images/warrior-main-2.png")
+	warrior, err := animations.LoadCharacter("assets/animations/warrior.json", spawn.PlayerClips...)
 	if err != nil {
-		// handle error
 		log.Fatal(err)
 	}
 
-	goblinFireImg, _, err := ebitenutil.NewImageFromFile("assets/images/goblin_fire.png")
+	goblin, err := animations.LoadCharacter("assets/animations/goblin.json", spawn.EnemyClips...)
 	if err != nil {
-		// handle error
 		log.Fatal(err)
 	}
 
@@ -255,8 +285,48 @@ func (g *GameScene) FirstLoad() {
 		log.Fatal(err)
 	}
 
+	g.playerSpriteSheet = warrior.Sheet
+	g.enemySpriteSheet = goblin.Sheet
+	g.tilemapImg = tilemapImg
+	g.spawnAssets = spawn.Assets{
+		Player: warrior,
+		Enemy:  goblin,
+		Potion: potionImg,
+	}
+
+	g.world, err = world.NewWorld("assets/maps/overworld.world")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	g.cam = camera.NewCamera(50, 50)
+	g.cam.Mode = camera.FollowMode{
+		Smoothing:      6,
+		DeadzoneWidth:  32,
+		DeadzoneHeight: 24,
+		LookAhead:      16,
+	}
+	g.cam.Shake = camera.ShakeMode{
+		MaxOffset:      6,
+		MaxAngle:       0.05,
+		Frequency:      12,
+		Decay:          1.5,
+		ImpulseDamping: 12,
+	}
+
+	// after the camera, the map can start a cutscene
+	g.loadMap("assets/maps/spawn.tmj")
+
+	g.loaded = true
+
+}
+
+// loadMap replaces the current map, its enemies and potions with the ones of
+// the map at mapPath. The player is only spawned from the first map, after
+// that it keeps its health and everything else and is moved by the caller.
+func (g *GameScene) loadMap(mapPath string) {
 	// Load tile map
-	tilemapJSON, err := tilemap.NewTilemapJSON("assets/maps/spawn.tmj")
+	tilemapJSON, err := tilemap.NewTilemap(mapPath)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -266,160 +336,131 @@ func (g *GameScene) FirstLoad() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	gids := tilemap.NewGidResolver(tilesets)
+
+	spawnLayer := tilemapJSON.ObjectLayer("spawn")
+	if spawnLayer == nil {
+		log.Fatalf("map %s has no spawn layer", mapPath)
+	}
+
+	spawned, err := spawn.Spawn(spawnLayer.Objects, g.spawnAssets)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if g.player == nil {
+		g.player = spawned.Player
+	}
+	g.enemies = spawned.Enemies
+	g.potions = spawned.Potions
 
-	playerSpriteSheet := spritesheet.NewSpriteSheet(6, 8, 192)
-	enemySpriteSheet := spritesheet.NewSpriteSheet(6, 5, 192)
-
-	g.player = &entities.Player{
-		Sprite: &entities.Sprite{
-			Img: playerImg,
-			X:   50,
-			Y:   50,
-		},
-		Health: 5,
-		Animations: map[entities.Direction]*animations.Animation{
-			entities.Right:          animations.NewAnimation(6, 11, 1, 8.0),
-			entities.Left:           animations.NewAnimation(48, 53, 1, 8.0),
-			entities.Down:           animations.NewAnimation(26, 30, 3, 8.0),
-			entities.Up:             animations.NewAnimation(38, 42, 3, 8.0),
-			entities.MouseLeftClick: animations.NewAnimation(12, 17, 1, 1.0),
-		},
-		CombatComp: components.NewBasicCombat(3, 1),
-	}
-
-	g.playerSpriteSheet = playerSpriteSheet
-	g.enemySpriteSheet = enemySpriteSheet
-
-	g.enemies = []*entities.Enemy{
-		{
-			Sprite: &entities.Sprite{
-				Img: goblinFireImg,
-				X:   200,
-				Y:   200,
-			},
-			FollowsPlayer: true,
-			Animations: map[entities.Direction]*animations.Animation{
-				entities.Right: animations.NewAnimation(7, 12, 1, 8.0),
-				entities.Left:  animations.NewAnimation(7, 12, 1, 8.0),
-				entities.Up:    animations.NewAnimation(7, 12, 1, 8.0),
-				entities.Down:  animations.NewAnimation(7, 12, 1, 8.0),
-			},
-			CombatComp: components.NewEnemyCombat(3, 1, 30),
-		},
-		{
-			Sprite: &entities.Sprite{
-				Img: goblinFireImg,
-				X:   250,
-				Y:   250,
-			},
-			FollowsPlayer: true,
-			Animations: map[entities.Direction]*animations.Animation{
-				entities.Right: animations.NewAnimation(7, 12, 1, 8.0),
-				entities.Left:  animations.NewAnimation(7, 12, 1, 8.0),
-				entities.Up:    animations.NewAnimation(7, 12, 1, 8.0),
-				entities.Down:  animations.NewAnimation(7, 12, 1, 8.0),
-			},
-			CombatComp: components.NewEnemyCombat(3, 1, 30),
-		},
-		{
-			Sprite: &entities.Sprite{
-				Img: goblinFireImg,
-				X:   180,
-				Y:   150,
-			},
-			FollowsPlayer: true,
-			Animations: map[entities.Direction]*animations.Animation{
-				entities.Right: animations.NewAnimation(7, 12, 1, 8.0),
-				entities.Left:  animations.NewAnimation(7, 12, 1, 8.0),
-				entities.Up:    animations.NewAnimation(7, 12, 1, 8.0),
-				entities.Down:  animations.NewAnimation(7, 12, 1, 8.0),
-			},
-			CombatComp: components.NewEnemyCombat(3, 1, 30),
-		},
-		{
-			Sprite: &entities.Sprite{
-				Img: goblinFireImg,
-				X:   300,
-				Y:   250,
-			},
-			FollowsPlayer: true,
-			Animations: map[entities.Direction]*animations.Animation{
-				entities.Right: animations.NewAnimation(7, 12, 1, 8.0),
-				entities.Left:  animations.NewAnimation(7, 12, 1, 8.0),
-				entities.Up:    animations.NewAnimation(7, 12, 1, 8.0),
-				entities.Down:  animations.NewAnimation(7, 12, 1, 8.0),
-			},
-			CombatComp: components.NewEnemyCombat(3, 1, 30),
-		},
-		{
-			Sprite: &entities.Sprite{
-				Img: goblinFireImg,
-				X:   250,
-				Y:   250,
-			},
-			FollowsPlayer: true,
-			Animations: map[entities.Direction]*animations.Animation{
-				entities.Right: animations.NewAnimation(7, 12, 1, 8.0),
-				entities.Left:  animations.NewAnimation(7, 12, 1, 8.0),
-				entities.Up:    animations.NewAnimation(7, 12, 1, 8.0),
-				entities.Down:  animations.NewAnimation(7, 12, 1, 8.0),
-			},
-			CombatComp: components.NewEnemyCombat(3, 1, 30),
-		},
-		{
-			Sprite: &entities.Sprite{
-				Img: goblinFireImg,
-				X:   200,
-				Y:   350,
-			},
-			FollowsPlayer: true,
-			Animations: map[entities.Direction]*animations.Animation{
-				entities.Right: animations.NewAnimation(7, 12, 1, 8.0),
-				entities.Left:  animations.NewAnimation(7, 12, 1, 8.0),
-				entities.Up:    animations.NewAnimation(7, 12, 1, 8.0),
-				entities.Down:  animations.NewAnimation(7, 12, 1, 8.0),
-			},
-			CombatComp: components.NewEnemyCombat(3, 1, 30),
-		},
-		{
-			Sprite: &entities.Sprite{
-				Img: goblinFireImg,
-				X:   150,
-				Y:   100,
-			},
-			FollowsPlayer: false,
-			Animations: map[entities.Direction]*animations.Animation{
-				entities.Right: animations.NewAnimation(7, 12, 1, 8.0),
-				entities.Left:  animations.NewAnimation(7, 12, 1, 8.0),
-				entities.Down:  animations.NewAnimation(7, 12, 1, 8.0),
-				entities.Up:    animations.NewAnimation(7, 12, 1, 8.0),
-			},
-			CombatComp: components.NewEnemyCombat(3, 1, 30),
-		},
-	}
-
-	g.potions = []*entities.Potion{
-		{
-			Sprite: &entities.Sprite{
-				Img: potionImg,
-				X:   120,
-				Y:   120,
-			},
-			AmtHeal: 5,
-		},
+	if g.mapRenderer != nil {
+		g.mapRenderer.Deallocate()
 	}
 
+	g.mapPath = mapPath
 	g.tilemapJSON = tilemapJSON
-	g.tilemapImg = tilemapImg
-	g.tilesets = tilesets
-	g.cam = camera.NewCamera(50, 50)
+	g.gids = gids
+	g.mapBounds = tilemapJSON.Bounds()
+	g.mapRenderer, err = tilemap.NewRenderer(tilemapJSON, gids)
+	if err != nil {
+		log.Fatal(err)
+	}
+	g.portals = world.Portals(tilemapJSON)
+	g.playCutscenes(tilemapJSON)
+
+	g.colliders = append(
+		tilemapJSON.Colliders("collision"),
+		tilemapJSON.TileColliders(gids)...,
+	)
+	g.mapColliders = len(g.colliders)
+}
 
-	g.colliders = []image.Rectangle{
-		image.Rect(100, 100, 116, 116),
+// playCutscenes plays the camera paths of the map's "camera" layer that are
+// set to autoplay one after another, each only the first time the map is entered.
+func (g *GameScene) playCutscenes(tilemapJSON *tilemap.TilemapJSON) {
+	layer := tilemapJSON.ObjectLayer("camera")
+	if layer == nil {
+		return
 	}
 
-	g.loaded = true
+	for _, obj := range layer.Objects {
+		if obj.Polyline == nil {
+			continue
+		}
+
+		path, err := camera.NewPath(obj)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		key := g.mapPath + "#" + path.Name
+		if _, played := g.cutscenesPlayed[key]; played || !path.Autoplay {
+			continue
+		}
+		g.cutscenesPlayed[key] = struct{}{}
+		g.cam.Queue(path)
+	}
+}
+
+// enterPortal moves the player to the portal's spawn point, loading its map first if it is another one.
+func (g *GameScene) enterPortal(portal world.Portal) {
+	if portal.Map != "" && portal.Map != g.mapPath {
+		g.loadMap(portal.Map)
+	}
+
+	x, y, found := spawn.Point(g.tilemapJSON.ObjectLayer("spawn").Objects, portal.Spawn)
+	if !found {
+		log.Fatalf("map %s has no spawn point %q", g.mapPath, portal.Spawn)
+	}
+	g.player.X = x
+	g.player.Y = y
+	g.cam.FollowTarget(g.player.X+8, g.player.Y+8, constants.ScreenWidth, constants.ScreenHeight)
+
+	// the spawn point may be on a portal back, it only works again once the player has stepped off
+	g.inPortal = true
+}
+
+// checkPortals sends the player through a portal they have just walked onto.
+func (g *GameScene) checkPortals(pRect image.Rectangle) {
+	touching := false
+	for _, portal := range g.portals {
+		if !portal.Bounds.Overlaps(pRect) {
+			continue
+		}
+
+		touching = true
+		if !g.inPortal {
+			g.enterPortal(portal)
+			return
+		}
+	}
+	g.inPortal = touching
+}
 
+// crossWorldEdge continues into the neighbouring map of the world when the
+// player walks off the edge of the current one.
+func (g *GameScene) crossWorldEdge() {
+	current := g.world.Find(g.mapPath)
+	if current == nil {
+		return
+	}
+
+	centerX := int(g.player.X) + constants.Tilesize/2
+	centerY := int(g.player.Y) + constants.Tilesize/2
+	if image.Pt(centerX, centerY).In(g.mapBounds) {
+		return
+	}
+
+	next := g.world.MapAt(current.X+centerX, current.Y+centerY)
+	if next == nil || next == current {
+		return
+	}
+
+	g.loadMap(g.world.MapPath(next))
+	g.player.X += float64(current.X - next.X)
+	g.player.Y += float64(current.Y - next.Y)
+	g.cam.FollowTarget(g.player.X+8, g.player.Y+8, constants.ScreenWidth, constants.ScreenHeight)
 }
 
 func (g *GameScene) Update() SceneId {
@@ -428,6 +469,10 @@ func (g *GameScene) Update() SceneId {
 		return ExitSceneId
 	}
 
+	if inpututil.IsKeyJustPressed(ebiten.KeyF3) {
+		g.debug = !g.debug
+	}
+
 	// set velocity to 0 initially to make it stop going in one direction on key press.
 	g.player.Dx = 0
 	g.player.Dy = 0
@@ -448,6 +493,19 @@ func (g *GameScene) Update() SceneId {
 		g.player.Dy += 2
 	}
 
+	// everyone waits while a cutscene has the camera, nobody moves or fights
+	cutscene := g.cam.Scripted()
+	if cutscene {
+		g.player.Dx = 0
+		g.player.Dy = 0
+	}
+
+	// mouse wheel zooms the camera
+	_, wheelY := ebiten.Wheel()
+	if wheelY != 0 {
+		g.cam.Zoom = math.Max(0.5, math.Min(4, g.cam.Zoom*math.Pow(1.1, wheelY)))
+	}
+
 	g.player.X += g.player.Dx
 
 	checkCollisonHorizontal(g.player.Sprite, g.colliders)
@@ -465,7 +523,7 @@ func (g *GameScene) Update() SceneId {
 		activeAnimation.Update()
 	}
 
-	clicked := inpututil.IsMouseButtonJustPressed(ebiten.MouseButton0)
+	clicked := inpututil.IsMouseButtonJustPressed(ebiten.MouseButton0) && !cutscene
 
 	if clicked {
 		g.player.CombatComp.Attack()
@@ -486,7 +544,7 @@ func (g *GameScene) Update() SceneId {
 		enemy.Dx = 0.0
 		enemy.Dy = 0.0
 
-		if enemy.FollowsPlayer {
+		if enemy.FollowsPlayer && !cutscene {
 			if enemy.X < g.player.X {
 				enemy.Dx += 1
 			} else if enemy.X > g.player.X {
@@ -517,9 +575,10 @@ func (g *GameScene) Update() SceneId {
 		}
 	}
 
-	cX, cY := ebiten.CursorPosition()
-	cX -= int(g.cam.X)
-	cY -= int(g.cam.Y)
+	// cursor in world pixels
+	screenX, screenY := ebiten.CursorPosition()
+	worldX, worldY := g.cam.ScreenToWorld(float64(screenX), float64(screenY), constants.ScreenWidth, constants.ScreenHeight)
+	cX, cY := int(math.Floor(worldX)), int(math.Floor(worldY))
 
 	g.player.CombatComp.Update()
 
@@ -533,6 +592,11 @@ func (g *GameScene) Update() SceneId {
 
 	deadEnemies := make(map[int]struct{})
 	for index, enemy := range g.enemies {
+		// attack cooldowns don't run down during a cutscene either
+		if cutscene {
+			break
+		}
+
 		enemy.CombatComp.Update()
 		rect := image.Rect(
 			int(enemy.X),
@@ -544,7 +608,16 @@ func (g *GameScene) Update() SceneId {
 		// if enemy overlaps player
 		if rect.Overlaps(pRect) {
 			if enemy.CombatComp.Attack() {
-				g.player.CombatComp.Damage(enemy.CombatComp.AttackPower())
+				health := g.player.CombatComp.Health()
+				damage := enemy.CombatComp.AttackPower()
+				g.player.CombatComp.Damage(damage)
+
+				// shake harder the more of the player's health the hit took, a
+				// single hit never shakes at full strength so the next one still shows
+				share := float64(damage) / math.Max(1, float64(health))
+				g.cam.AddTrauma(math.Min(0.8, 0.2+0.6*share))
+				// and knock the view away from the enemy
+				g.cam.Impulse(knockback(g.player.X-enemy.X), knockback(g.player.Y-enemy.Y))
 
 				if g.player.CombatComp.Health() <= 0 {
 					fmt.Println("The Player has died...   ")
@@ -581,14 +654,24 @@ func (g *GameScene) Update() SceneId {
 	// 	}
 	// }
 
+	g.checkPortals(image.Rect(
+		int(g.player.X),
+		int(g.player.Y),
+		int(g.player.X)+constants.Tilesize,
+		int(g.player.Y)+constants.Tilesize,
+	))
+	g.crossWorldEdge()
+
 	// Add camera to follow player
-	g.cam.FollowTarget(g.player.X+8, g.player.Y+8, 320, 240)
-	g.cam.Constrain(
-		float64(g.tilemapJSON.Layers[0].Width)*16,
-		float64(g.tilemapJSON.Layers[0].Height)*16,
-		320,
-		240,
-	)
+	g.cam.Update()
+	g.cam.Follow(g.player.X+8, g.player.Y+8, g.player.Dx, g.player.Dy, constants.ScreenWidth, constants.ScreenHeight)
+	g.cam.ConstrainBounds(g.mapBounds, constants.ScreenWidth, constants.ScreenHeight)
+
+	err := g.mapRenderer.Update(g.cam.View(constants.ScreenWidth, constants.ScreenHeight))
+	if err != nil {
+		log.Fatal(err)
+	}
+	g.colliders = append(g.colliders[:g.mapColliders], g.mapRenderer.Colliders()...)
 
 	return GameSceneId
 }
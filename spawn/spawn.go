@@ -0,0 +1,138 @@
+package spawn
+
+import (
+	"errors"
+	"rpg-game-go/animations"
+	"rpg-game-go/components"
+	"rpg-game-go/entities"
+	"rpg-game-go/objects"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Object types recognised in the spawn layer.
+const (
+	PlayerStart = "player_start"
+	Enemy       = "enemy"
+	Potion      = "potion"
+)
+
+type Assets struct {
+	Player *animations.Character
+	Enemy  *animations.Character
+	Potion *ebiten.Image
+}
+
+// Clips of a character definition, by the direction they animate.
+var clipDirections = map[string]entities.Direction{
+	"right":  entities.Right,
+	"left":   entities.Left,
+	"up":     entities.Up,
+	"down":   entities.Down,
+	"attack": entities.MouseLeftClick,
+}
+
+// Clips the characters in Assets must have, pass them to animations.LoadCharacter.
+var (
+	PlayerClips = []string{"right", "left", "up", "down", "attack"}
+	EnemyClips  = []string{"right", "left", "up", "down"}
+)
+
+type Entities struct {
+	Player  *entities.Player
+	Enemies []*entities.Enemy
+	Potions []*entities.Potion
+}
+
+// Spawn builds the player, enemies and potions placed as objects in a Tiled object layer.
+// Objects of any other type are ignored.
+func Spawn(objs []*objects.ObjectJSON, assets Assets) (*Entities, error) {
+	spawned := &Entities{
+		Player:  nil,
+		Enemies: make([]*entities.Enemy, 0),
+		Potions: make([]*entities.Potion, 0),
+	}
+
+	for _, obj := range objs {
+		switch obj.Kind() {
+		case PlayerStart:
+			spawned.Player = newPlayer(obj, assets.Player)
+		case Enemy:
+			spawned.Enemies = append(spawned.Enemies, newEnemy(obj, assets.Enemy))
+		case Potion:
+			spawned.Potions = append(spawned.Potions, newPotion(obj, assets.Potion))
+		}
+	}
+
+	if spawned.Player == nil {
+		return nil, errors.New("spawn: no player_start object in spawn layer")
+	}
+
+	return spawned, nil
+}
+
+// Point returns the position of the object with the given name, where a player
+// arriving through a portal is placed. An empty name means the player_start.
+func Point(objs []*objects.ObjectJSON, name string) (float64, float64, bool) {
+	for _, obj := range objs {
+		if (name == "" && obj.Kind() == PlayerStart) || (name != "" && obj.Name == name) {
+			return obj.X, obj.Y, true
+		}
+	}
+	return 0, 0, false
+}
+
+// Fresh animations for every clip of the character, each entity needs its own.
+func characterAnimations(character *animations.Character) map[entities.Direction]*animations.Animation {
+	anims := make(map[entities.Direction]*animations.Animation)
+	for name, direction := range clipDirections {
+		if anim := character.Clip(name); anim != nil {
+			anims[direction] = anim
+		}
+	}
+	return anims
+}
+
+func newPlayer(obj *objects.ObjectJSON, character *animations.Character) *entities.Player {
+	return &entities.Player{
+		Sprite: &entities.Sprite{
+			Img: character.Img,
+			X:   obj.X,
+			Y:   obj.Y,
+		},
+		Health:     5,
+		Animations: characterAnimations(character),
+		CombatComp: components.NewBasicCombat(
+			obj.Properties.Int("health", 3),
+			obj.Properties.Int("attackPower", 1),
+		),
+	}
+}
+
+func newEnemy(obj *objects.ObjectJSON, character *animations.Character) *entities.Enemy {
+	return &entities.Enemy{
+		Sprite: &entities.Sprite{
+			Img: character.Img,
+			X:   obj.X,
+			Y:   obj.Y,
+		},
+		FollowsPlayer: obj.Properties.Bool("followsPlayer", true),
+		Animations:    characterAnimations(character),
+		CombatComp: components.NewEnemyCombat(
+			obj.Properties.Int("health", 3),
+			obj.Properties.Int("attackPower", 1),
+			obj.Properties.Int("attackCooldown", 30),
+		),
+	}
+}
+
+func newPotion(obj *objects.ObjectJSON, img *ebiten.Image) *entities.Potion {
+	return &entities.Potion{
+		Sprite: &entities.Sprite{
+			Img: img,
+			X:   obj.X,
+			Y:   obj.Y,
+		},
+		AmtHeal: uint(obj.Properties.Int("amtHeal", 5)),
+	}
+}
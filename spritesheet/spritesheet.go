@@ -7,20 +7,22 @@ import (
 type Spritesheet struct {
 	WidthInTiles  int
 	HeightInTiles int
-	Tilesize      int
+	TileWidth     int
+	TileHeight    int
 }
 
 func (s *Spritesheet) Rect(index int) image.Rectangle {
-	x := (index % s.WidthInTiles) * s.Tilesize
-	y := (index / s.WidthInTiles) * s.Tilesize
+	x := (index % s.WidthInTiles) * s.TileWidth
+	y := (index / s.WidthInTiles) * s.TileHeight
 
 	return image.Rect(
-		x, y, x+s.Tilesize, y+s.Tilesize,
+		x, y, x+s.TileWidth, y+s.TileHeight,
 	)
 }
 
+// NewSpriteSheet is a sheet of w by h square tiles, t pixels wide.
 func NewSpriteSheet(w, h, t int) *Spritesheet {
 	return &Spritesheet{
-		w, h, t,
+		w, h, t, t,
 	}
 }
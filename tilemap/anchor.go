@@ -0,0 +1,93 @@
+package tilemap
+
+import (
+	"image"
+	"math"
+	"rpg-game-go/objects"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// cellRect is where a w by h tile image in a tile layer goes, in pixels
+// relative to the layer. Tiled lines up the bottom left of the image with the
+// bottom left of the cell, so tiles taller or wider than a cell grow up and to
+// the right, then moves it by the tileset's tile offset.
+func (t *TilemapJSON) cellRect(col, row, w, h int, offset image.Point) image.Rectangle {
+	x := col*t.TileWidth + offset.X
+	y := (row+1)*t.TileHeight - h + offset.Y
+	return image.Rect(x, y, x+w, y+h)
+}
+
+// alignmentAnchor is the point of a tile object's image that sits on the
+// object's position, as fractions of the object's width and height.
+func alignmentAnchor(alignment string) (float64, float64) {
+	switch alignment {
+	case "topleft":
+		return 0, 0
+	case "top":
+		return 0.5, 0
+	case "topright":
+		return 1, 0
+	case "left":
+		return 0, 0.5
+	case "center":
+		return 0.5, 0.5
+	case "right":
+		return 1, 0.5
+	case "bottom":
+		return 0.5, 1
+	case "bottomright":
+		return 1, 1
+	default:
+		// "bottomleft", and "unspecified" which is bottom left on orthogonal maps
+		return 0, 1
+	}
+}
+
+// objectGeoM places the image of a tile object, relative to its layer. The
+// image is stretched to the object's size, anchored at its position by the
+// tileset's object alignment and rotated around that same point.
+// gid is the object's gid with the flip flags split off into flip.
+func (r *Renderer) objectGeoM(obj *objects.ObjectJSON, gid int, img *ebiten.Image, flip FlipFlags) ebiten.GeoM {
+	geom := ebiten.GeoM{}
+
+	ts, _ := r.gids.Resolve(gid)
+	imgW, imgH := float64(img.Bounds().Dx()), float64(img.Bounds().Dy())
+	flippedW, flippedH := flip.Size(img.Bounds().Dx(), img.Bounds().Dy())
+
+	w, h := obj.Width, obj.Height
+	if w == 0 || h == 0 {
+		w, h = float64(flippedW), float64(flippedH)
+	}
+
+	anchorX, anchorY := alignmentAnchor(ts.ObjectAlignment())
+	offset := ts.TileOffset()
+
+	flip.Apply(&geom, imgW, imgH)
+	geom.Scale(w/float64(flippedW), h/float64(flippedH))
+	geom.Translate(-anchorX*w, -anchorY*h)
+	geom.Rotate(obj.Rotation * math.Pi / 180)
+	geom.Translate(obj.X+float64(offset.X), obj.Y+float64(offset.Y))
+	return geom
+}
+
+// objectRect is the area a tile object's image covers, relative to its
+// layer. Rotated objects get the rectangle around their corners.
+func objectRect(geom ebiten.GeoM, img *ebiten.Image) image.Rectangle {
+	w, h := float64(img.Bounds().Dx()), float64(img.Bounds().Dy())
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, corner := range [][2]float64{{0, 0}, {w, 0}, {0, h}, {w, h}} {
+		x, y := geom.Apply(corner[0], corner[1])
+		minX, minY = math.Min(minX, x), math.Min(minY, y)
+		maxX, maxY = math.Max(maxX, x), math.Max(maxY, y)
+	}
+
+	return image.Rect(
+		int(math.Floor(minX)),
+		int(math.Floor(minY)),
+		int(math.Ceil(maxX)),
+		int(math.Ceil(maxY)),
+	)
+}
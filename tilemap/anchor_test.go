@@ -0,0 +1,165 @@
+package tilemap
+
+import (
+	"image"
+	"math"
+	"rpg-game-go/objects"
+	"rpg-game-go/tileset"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestCellRect(t *testing.T) {
+	m := &TilemapJSON{TileWidth: 16, TileHeight: 16}
+
+	tests := []struct {
+		name     string
+		col, row int
+		w, h     int
+		offset   image.Point
+		want     image.Rectangle
+	}{
+		{"one cell", 2, 3, 16, 16, image.Point{}, image.Rect(32, 48, 48, 64)},
+		// bottom left on the cell's bottom left, growing up and right
+		{"tall tile", 2, 3, 32, 48, image.Point{}, image.Rect(32, 16, 64, 64)},
+		{"wide tile", 0, 0, 48, 16, image.Point{}, image.Rect(0, 0, 48, 16)},
+		{"small tile", 1, 1, 8, 8, image.Point{}, image.Rect(16, 24, 24, 32)},
+		{"tile offset", 2, 3, 32, 48, image.Pt(4, -8), image.Rect(36, 8, 68, 56)},
+		{"negative cell", -1, -1, 16, 16, image.Point{}, image.Rect(-16, -16, 0, 0)},
+	}
+
+	for _, test := range tests {
+		got := m.cellRect(test.col, test.row, test.w, test.h, test.offset)
+		if got != test.want {
+			t.Errorf("%s: cellRect(%d, %d, %d, %d, %v) = %v, want %v", test.name, test.col, test.row, test.w, test.h, test.offset, got, test.want)
+		}
+	}
+}
+
+func TestAlignmentAnchor(t *testing.T) {
+	tests := []struct {
+		alignment string
+		x, y      float64
+	}{
+		{"topleft", 0, 0},
+		{"top", 0.5, 0},
+		{"topright", 1, 0},
+		{"left", 0, 0.5},
+		{"center", 0.5, 0.5},
+		{"right", 1, 0.5},
+		{"bottomleft", 0, 1},
+		{"bottom", 0.5, 1},
+		{"bottomright", 1, 1},
+		{"unspecified", 0, 1},
+		{"", 0, 1},
+	}
+
+	for _, test := range tests {
+		x, y := alignmentAnchor(test.alignment)
+		if x != test.x || y != test.y {
+			t.Errorf("alignmentAnchor(%q) = %v, %v, want %v, %v", test.alignment, x, y, test.x, test.y)
+		}
+	}
+}
+
+// Where geom puts a w x h image, rounded so rotations by right angles come
+// out exact, and where the image's top left corner ends up.
+func placed(geom ebiten.GeoM, w, h float64) (image.Rectangle, image.Point) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, corner := range [][2]float64{{0, 0}, {w, 0}, {0, h}, {w, h}} {
+		x, y := geom.Apply(corner[0], corner[1])
+		minX, minY = math.Min(minX, x), math.Min(minY, y)
+		maxX, maxY = math.Max(maxX, x), math.Max(maxY, y)
+	}
+	rect := image.Rect(int(math.Round(minX)), int(math.Round(minY)), int(math.Round(maxX)), int(math.Round(maxY)))
+
+	x, y := geom.Apply(0, 0)
+	return rect, image.Pt(int(math.Round(x)), int(math.Round(y)))
+}
+
+// Tile objects using a 32x48 image, at (100, 200).
+func TestObjectGeoM(t *testing.T) {
+	img := ebiten.NewImage(32, 48)
+
+	tests := []struct {
+		name      string
+		alignment string
+		offset    image.Point
+		obj       objects.ObjectJSON
+		flip      FlipFlags
+		want      image.Rectangle
+		origin    image.Point // where the image's top left corner goes
+	}{
+		{"bottom left", "", image.Point{}, objects.ObjectJSON{}, 0, image.Rect(100, 152, 132, 200), image.Pt(100, 152)},
+		{"tile offset", "", image.Pt(4, -2), objects.ObjectJSON{}, 0, image.Rect(104, 150, 136, 198), image.Pt(104, 150)},
+		{"stretched", "", image.Point{}, objects.ObjectJSON{Width: 64, Height: 24}, 0, image.Rect(100, 176, 164, 200), image.Pt(100, 176)},
+
+		{"topleft", "topleft", image.Point{}, objects.ObjectJSON{}, 0, image.Rect(100, 200, 132, 248), image.Pt(100, 200)},
+		{"top", "top", image.Point{}, objects.ObjectJSON{}, 0, image.Rect(84, 200, 116, 248), image.Pt(84, 200)},
+		{"topright", "topright", image.Point{}, objects.ObjectJSON{}, 0, image.Rect(68, 200, 100, 248), image.Pt(68, 200)},
+		{"left", "left", image.Point{}, objects.ObjectJSON{}, 0, image.Rect(100, 176, 132, 224), image.Pt(100, 176)},
+		{"center", "center", image.Point{}, objects.ObjectJSON{}, 0, image.Rect(84, 176, 116, 224), image.Pt(84, 176)},
+		{"right", "right", image.Point{}, objects.ObjectJSON{}, 0, image.Rect(68, 176, 100, 224), image.Pt(68, 176)},
+		{"bottomleft", "bottomleft", image.Point{}, objects.ObjectJSON{}, 0, image.Rect(100, 152, 132, 200), image.Pt(100, 152)},
+		{"bottom", "bottom", image.Point{}, objects.ObjectJSON{}, 0, image.Rect(84, 152, 116, 200), image.Pt(84, 152)},
+		{"bottomright", "bottomright", image.Point{}, objects.ObjectJSON{}, 0, image.Rect(68, 152, 100, 200), image.Pt(68, 152)},
+
+		// turned clockwise around the anchor
+		{"rotated 90", "", image.Point{}, objects.ObjectJSON{Rotation: 90}, 0, image.Rect(100, 200, 148, 232), image.Pt(148, 200)},
+		{"rotated 180", "", image.Point{}, objects.ObjectJSON{Rotation: 180}, 0, image.Rect(68, 200, 100, 248), image.Pt(100, 248)},
+		{"rotated -90", "", image.Point{}, objects.ObjectJSON{Rotation: -90}, 0, image.Rect(52, 168, 100, 200), image.Pt(52, 200)},
+		{"rotated 90 around the center", "center", image.Point{}, objects.ObjectJSON{Rotation: 90}, 0, image.Rect(76, 184, 124, 216), image.Pt(124, 184)},
+
+		{"flipped horizontally", "", image.Point{}, objects.ObjectJSON{}, FlipHorizontal, image.Rect(100, 152, 132, 200), image.Pt(132, 152)},
+		{"flipped vertically", "", image.Point{}, objects.ObjectJSON{}, FlipVertical, image.Rect(100, 152, 132, 200), image.Pt(100, 200)},
+		// the image turns on its side, 48x32
+		{"flipped diagonally", "", image.Point{}, objects.ObjectJSON{}, FlipDiagonal, image.Rect(100, 168, 148, 200), image.Pt(100, 168)},
+		{"flipped diagonally and horizontally", "", image.Point{}, objects.ObjectJSON{}, FlipDiagonal | FlipHorizontal, image.Rect(100, 168, 148, 200), image.Pt(148, 168)},
+		{"flipped and rotated", "", image.Point{}, objects.ObjectJSON{Rotation: 90}, FlipHorizontal, image.Rect(100, 200, 148, 232), image.Pt(148, 232)},
+	}
+
+	for _, test := range tests {
+		r := &Renderer{gids: NewGidResolver([]tileset.Tileset{&stubTileset{
+			firstGid:  1,
+			img:       img,
+			offset:    test.offset,
+			alignment: test.alignment,
+		}})}
+
+		obj := test.obj
+		obj.X, obj.Y = 100, 200
+		obj.Gid = 1 | int(test.flip)
+
+		geom := r.objectGeoM(&obj, 1, img, test.flip)
+		rect, origin := placed(geom, 32, 48)
+		if rect != test.want {
+			t.Errorf("%s: image at %v, want %v", test.name, rect, test.want)
+		}
+		if origin != test.origin {
+			t.Errorf("%s: top left of the image at %v, want %v", test.name, origin, test.origin)
+		}
+	}
+}
+
+func TestObjectRect(t *testing.T) {
+	img := ebiten.NewImage(32, 48)
+
+	var geom ebiten.GeoM
+	geom.Translate(100.5, 152.25)
+	if got, want := objectRect(geom, img), image.Rect(100, 152, 133, 201); got != want {
+		t.Errorf("objectRect = %v, want %v", got, want)
+	}
+
+	// a square turned 45° is covered by the rectangle around its corners
+	geom.Reset()
+	geom.Rotate(math.Pi / 4)
+	geom.Translate(100, 100)
+	got := objectRect(geom, ebiten.NewImage(10, 10))
+	diagonal := 10 * math.Sqrt2 / 2
+	want := image.Rect(int(math.Floor(100-diagonal)), 100, int(math.Ceil(100+diagonal)), int(math.Ceil(100+2*diagonal)))
+	if got != want {
+		t.Errorf("objectRect of a turned square = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,221 @@
+package tilemap
+
+import (
+	"image"
+	"rpg-game-go/camera"
+	"rpg-game-go/render"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Size of the blocks finite tile layers are baked in, in tiles.
+const blockSize = 16
+
+// A rectangle of tiles of one layer, baked into a single image so drawing it
+// costs one DrawImage instead of one per tile. Animated tiles can't be baked,
+// they are drawn on top of the image every frame, and neither can the tiles of
+// y-sorted layers, which go into the render queue one by one.
+type tileBlock struct {
+	tiles  *TileData
+	stride int             // width of a row in tiles.Data
+	area   image.Rectangle // tiles of the block, as columns and rows of tiles.Data
+	origin image.Point     // world position of the first tile in tiles.Data, in tiles
+
+	img     *ebiten.Image   // nil if no tile was baked
+	imgRect image.Rectangle // where img goes, in pixels relative to the layer
+	bounds  image.Rectangle // everything the block draws, in pixels relative to the layer
+	live    []int           // indices into tiles.Data of the tiles drawn every frame
+	sorted  bool
+	dirty   bool
+}
+
+// Splits a finite layer into blocks, row by row.
+func newLayerBlocks(layer *TilemapLayerJSON) []*tileBlock {
+	sorted := layer.YSorted()
+	blocks := make([]*tileBlock, 0)
+
+	for y := 0; y < layer.Height; y += blockSize {
+		for x := 0; x < layer.Width; x += blockSize {
+			blocks = append(blocks, &tileBlock{
+				tiles:  &layer.TileData,
+				stride: layer.Width,
+				area:   image.Rect(x, y, min(x+blockSize, layer.Width), min(y+blockSize, layer.Height)),
+				origin: image.Pt(0, 0),
+				sorted: sorted,
+				dirty:  true,
+			})
+		}
+	}
+	return blocks
+}
+
+// A streamed chunk is baked whole.
+func newChunkBlock(chunk *ChunkJSON, layer *TilemapLayerJSON) *tileBlock {
+	return &tileBlock{
+		tiles:  &chunk.TileData,
+		stride: chunk.Width,
+		area:   image.Rect(0, 0, chunk.Width, chunk.Height),
+		origin: image.Pt(chunk.X, chunk.Y),
+		sorted: layer.YSorted(),
+		dirty:  true,
+	}
+}
+
+// Block of a finite layer holding the tile at (col, row).
+func layerBlock(blocks []*tileBlock, layer *TilemapLayerJSON, col, row int) *tileBlock {
+	perRow := (layer.Width + blockSize - 1) / blockSize
+	return blocks[(row/blockSize)*perRow+col/blockSize]
+}
+
+// tileRect is the area the image of the tile at index covers, in pixels
+// relative to the layer. img may be a frame of the tile's animation.
+func (r *Renderer) tileRect(b *tileBlock, index int, img *ebiten.Image, flip FlipFlags) image.Rectangle {
+	col := b.origin.X + index%b.stride
+	row := b.origin.Y + index/b.stride
+
+	w, h := flip.Size(img.Bounds().Dx(), img.Bounds().Dy())
+	return r.tilemap.cellRect(col, row, w, h, r.gids.TileOffset(b.tiles.Data[index]))
+}
+
+func drawTile(dst *ebiten.Image, op *ebiten.DrawImageOptions, img *ebiten.Image, flip FlipFlags, x, y float64) {
+	op.GeoM.Reset()
+	flip.Apply(&op.GeoM, float64(img.Bounds().Dx()), float64(img.Bounds().Dy()))
+	op.GeoM.Translate(x, y)
+	dst.DrawImage(img, op)
+}
+
+// bake redraws the block's image from its tiles.
+func (r *Renderer) bake(b *tileBlock) {
+	if b.img != nil {
+		b.img.Deallocate()
+		b.img = nil
+	}
+	b.imgRect = image.Rectangle{}
+	b.bounds = image.Rectangle{}
+	b.live = b.live[:0]
+	b.dirty = false
+
+	static := make([]int, 0)
+	for row := b.area.Min.Y; row < b.area.Max.Y; row++ {
+		for col := b.area.Min.X; col < b.area.Max.X; col++ {
+			index := row*b.stride + col
+			if index >= len(b.tiles.Data) || b.tiles.Data[index] == 0 {
+				continue
+			}
+
+			id := b.tiles.Data[index]
+			img := r.gids.Img(id)
+			if img == nil {
+				continue
+			}
+
+			rect := r.tileRect(b, index, img, b.tiles.Flip(index))
+			b.bounds = b.bounds.Union(rect)
+
+			if b.sorted || r.gids.Animated(id) {
+				b.live = append(b.live, index)
+				continue
+			}
+			b.imgRect = b.imgRect.Union(rect)
+			static = append(static, index)
+		}
+	}
+
+	if len(static) == 0 {
+		return
+	}
+
+	b.img = ebiten.NewImage(b.imgRect.Dx(), b.imgRect.Dy())
+	op := &ebiten.DrawImageOptions{}
+	for _, index := range static {
+		img := r.gids.Img(b.tiles.Data[index])
+		flip := b.tiles.Flip(index)
+		rect := r.tileRect(b, index, img, flip)
+
+		drawTile(b.img, op, img, flip, float64(rect.Min.X-b.imgRect.Min.X), float64(rect.Min.Y-b.imgRect.Min.Y))
+	}
+}
+
+// What drawBlock needs to know about the layer being drawn.
+type layerDraw struct {
+	screen *ebiten.Image
+	op     *ebiten.DrawImageOptions
+	// where the layer's origin is on screen
+	offsetX, offsetY float64
+	// from the layer to the world, parallax layers don't move with the camera
+	// so this goes through the screen
+	toWorld image.Point
+	// the camera's zoom and rotation, applied after the offset
+	lens   ebiten.GeoM
+	culler *camera.Culler
+	queue  *render.Queue
+	sorted bool
+}
+
+// draw draws a single image placed by geom relative to the layer, covering
+// rect, if it is on screen. Images of y-sorted layers are pushed to the
+// render queue instead.
+func (d *layerDraw) draw(img *ebiten.Image, geom ebiten.GeoM, rect image.Rectangle) {
+	// a pixel of slack for the fractional part of the offset
+	if !d.culler.Visible(rect.Add(d.toWorld).Inset(-1)) {
+		return
+	}
+	geom.Translate(d.offsetX, d.offsetY)
+	geom.Concat(d.lens)
+
+	if !d.sorted {
+		d.op.GeoM = geom
+		d.screen.DrawImage(img, d.op)
+		return
+	}
+
+	// the options are reused for the next image, the queue draws later
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM = geom
+	op.ColorScale = d.op.ColorScale
+	d.queue.Push(float64(rect.Max.Y+d.toWorld.Y), func(screen *ebiten.Image) {
+		screen.DrawImage(img, op)
+	})
+}
+
+// drawBlock draws the block if it is on screen. Tiles of y-sorted blocks are
+// pushed to the render queue instead.
+func (r *Renderer) drawBlock(d *layerDraw, b *tileBlock) {
+	if b.dirty {
+		r.bake(b)
+	}
+
+	if !d.culler.Visible(b.bounds.Add(d.toWorld).Inset(-1)) {
+		return
+	}
+
+	if b.img != nil {
+		d.op.GeoM.Reset()
+		d.op.GeoM.Translate(float64(b.imgRect.Min.X)+d.offsetX, float64(b.imgRect.Min.Y)+d.offsetY)
+		d.op.GeoM.Concat(d.lens)
+		d.screen.DrawImage(b.img, d.op)
+	}
+
+	for _, index := range b.live {
+		id := b.tiles.Data[index]
+		img := r.gids.Img(r.gids.Animate(id, int(r.clock)))
+		if img == nil {
+			continue
+		}
+
+		flip := b.tiles.Flip(index)
+		rect := r.tileRect(b, index, img, flip)
+
+		geom := ebiten.GeoM{}
+		flip.Apply(&geom, float64(img.Bounds().Dx()), float64(img.Bounds().Dy()))
+		geom.Translate(float64(rect.Min.X), float64(rect.Min.Y))
+		d.draw(img, geom, rect)
+	}
+}
+
+func (b *tileBlock) deallocate() {
+	if b.img != nil {
+		b.img.Deallocate()
+		b.img = nil
+	}
+}
@@ -0,0 +1,180 @@
+package tilemap
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+)
+
+// A chunk of a tile layer in an infinite map. X, Y, Width and Height are in tiles.
+// The data stays encoded until the chunk is streamed in.
+type ChunkJSON struct {
+	TileData
+	RawData json.RawMessage `json:"data"`
+	X       int             `json:"x"`
+	Y       int             `json:"y"`
+	Width   int             `json:"width"`
+	Height  int             `json:"height"`
+
+	encoding    string
+	compression string
+	edited      bool // tiles were changed since the chunk was loaded
+}
+
+func (c *ChunkJSON) Loaded() bool {
+	return c.Data != nil
+}
+
+func (c *ChunkJSON) load() error {
+	return c.decode(c.RawData, c.encoding, c.compression, c.Width*c.Height)
+}
+
+func (c *ChunkJSON) unload() {
+	// decoding the chunk again would lose the edits, so they are written back
+	if c.edited {
+		// can't fail for a slice of ints
+		c.RawData, _ = json.Marshal(c.raw())
+		c.encoding = "csv"
+		c.compression = ""
+		c.edited = false
+	}
+
+	c.Data = nil
+	c.Flips = nil
+}
+
+type chunkKey struct {
+	layer int
+	x, y  int // position in chunks, not tiles
+}
+
+type chunkRange struct {
+	minX, minY, maxX, maxY int // inclusive
+}
+
+func (r chunkRange) contains(key chunkKey) bool {
+	return key.x >= r.minX && key.x <= r.maxX && key.y >= r.minY && key.y <= r.maxY
+}
+
+// ChunkStreamer keeps the chunks of an infinite map near the view decoded and
+// drops the rest, so only the area around the camera lives in memory.
+type ChunkStreamer struct {
+	tilemap *TilemapJSON
+	// chunk size in tiles, Tiled uses the same size for the whole map
+	chunkWidth  int
+	chunkHeight int
+	// how many chunks around the view stay loaded
+	margin int
+
+	chunks map[chunkKey]*ChunkJSON
+	loaded map[chunkKey]*ChunkJSON
+	view   chunkRange
+}
+
+func NewChunkStreamer(t *TilemapJSON, margin int) *ChunkStreamer {
+	s := &ChunkStreamer{
+		tilemap:     t,
+		chunkWidth:  16,
+		chunkHeight: 16,
+		margin:      margin,
+		chunks:      make(map[chunkKey]*ChunkJSON),
+		loaded:      make(map[chunkKey]*ChunkJSON),
+	}
+
+	for layerIndex, layer := range t.FlatLayers() {
+		for _, chunk := range layer.Chunks {
+			if chunk.Width > 0 && chunk.Height > 0 {
+				s.chunkWidth = chunk.Width
+				s.chunkHeight = chunk.Height
+			}
+			s.chunks[s.key(layerIndex, chunk.X, chunk.Y)] = chunk
+		}
+	}
+	return s
+}
+
+func floorDiv(a, b int) int {
+	if a < 0 && a%b != 0 {
+		return a/b - 1
+	}
+	return a / b
+}
+
+// key of the chunk containing the tile at (x, y).
+func (s *ChunkStreamer) key(layer, x, y int) chunkKey {
+	return chunkKey{
+		layer: layer,
+		x:     floorDiv(x, s.chunkWidth),
+		y:     floorDiv(y, s.chunkHeight),
+	}
+}
+
+// Chunks overlapping a rectangle in pixels, grown by margin chunks on every side.
+func (s *ChunkStreamer) chunkRange(rect image.Rectangle, margin int) chunkRange {
+	w := s.chunkWidth * s.tilemap.TileWidth
+	h := s.chunkHeight * s.tilemap.TileHeight
+
+	return chunkRange{
+		minX: floorDiv(rect.Min.X, w) - margin,
+		minY: floorDiv(rect.Min.Y, h) - margin,
+		maxX: floorDiv(rect.Max.X-1, w) + margin,
+		maxY: floorDiv(rect.Max.Y-1, h) + margin,
+	}
+}
+
+// Update loads the chunks around view (in world pixels) and unloads chunks
+// that have moved further away. Unloading waits for one extra chunk of
+// distance so walking back and forth over a chunk edge doesn't thrash.
+func (s *ChunkStreamer) Update(view image.Rectangle) error {
+	// one chunk of slack, tiles taller or wider than a cell reach into the view from neighbouring chunks
+	s.view = s.chunkRange(view, 1)
+	keep := s.chunkRange(view, s.margin+1)
+
+	for key, chunk := range s.loaded {
+		if !keep.contains(key) {
+			chunk.unload()
+			delete(s.loaded, key)
+		}
+	}
+
+	load := s.chunkRange(view, s.margin)
+	for layerIndex, layer := range s.tilemap.FlatLayers() {
+		if layer.Type != TileLayer || len(layer.Chunks) == 0 {
+			continue
+		}
+
+		for y := load.minY; y <= load.maxY; y++ {
+			for x := load.minX; x <= load.maxX; x++ {
+				key := chunkKey{layerIndex, x, y}
+
+				chunk, exists := s.chunks[key]
+				if !exists || chunk.Loaded() {
+					continue
+				}
+
+				err := chunk.load()
+				if err != nil {
+					return fmt.Errorf("tilemap: layer %q chunk (%d, %d): %w", layer.Name, chunk.X, chunk.Y, err)
+				}
+				s.loaded[key] = chunk
+			}
+		}
+	}
+	return nil
+}
+
+// Visible returns the loaded chunks of a layer (its index in FlatLayers) that
+// overlap the last view passed to Update, top to bottom and left to right.
+func (s *ChunkStreamer) Visible(layerIndex int) []*ChunkJSON {
+	visible := make([]*ChunkJSON, 0)
+
+	for y := s.view.minY; y <= s.view.maxY; y++ {
+		for x := s.view.minX; x <= s.view.maxX; x++ {
+			chunk, exists := s.loaded[chunkKey{layerIndex, x, y}]
+			if exists {
+				visible = append(visible, chunk)
+			}
+		}
+	}
+	return visible
+}
@@ -0,0 +1,172 @@
+package tilemap
+
+import (
+	"encoding/json"
+	"image"
+	"testing"
+)
+
+func TestFloorDiv(t *testing.T) {
+	tests := []struct {
+		a, b, want int
+	}{
+		{0, 16, 0},
+		{15, 16, 0},
+		{16, 16, 1},
+		{33, 16, 2},
+		{-1, 16, -1},
+		{-15, 16, -1},
+		{-16, 16, -1},
+		{-17, 16, -2},
+		{-32, 16, -2},
+		{-33, 16, -3},
+	}
+
+	for _, test := range tests {
+		got := floorDiv(test.a, test.b)
+		if got != test.want {
+			t.Errorf("floorDiv(%d, %d) = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestChunkRange(t *testing.T) {
+	// chunks of 16x16 tiles of 10x10 pixels, 160x160 pixels each
+	s := &ChunkStreamer{
+		tilemap:     &TilemapJSON{TileWidth: 10, TileHeight: 10},
+		chunkWidth:  16,
+		chunkHeight: 16,
+	}
+
+	tests := []struct {
+		name   string
+		rect   image.Rectangle
+		margin int
+		want   chunkRange
+	}{
+		{"inside the first chunk", image.Rect(0, 0, 160, 160), 0, chunkRange{0, 0, 0, 0}},
+		{"one pixel over", image.Rect(0, 0, 161, 160), 0, chunkRange{0, 0, 1, 0}},
+		{"across the origin", image.Rect(-1, -160, 1, 1), 0, chunkRange{-1, -1, 0, 0}},
+		{"negative only", image.Rect(-320, -161, -160, -1), 0, chunkRange{-2, -2, -2, -1}},
+		{"with margin", image.Rect(200, 200, 300, 300), 2, chunkRange{-1, -1, 3, 3}},
+	}
+
+	for _, test := range tests {
+		got := s.chunkRange(test.rect, test.margin)
+		if got != test.want {
+			t.Errorf("%s: chunkRange(%v, %d) = %+v, want %+v", test.name, test.rect, test.margin, got, test.want)
+		}
+	}
+}
+
+// An infinite map with one layer of 2x2 tile chunks from (-2, -2) to (2, 2)
+// tiles, so chunks -1 and 0 on both axes. Tiles are 10x10 pixels.
+func chunkedMap() *TilemapJSON {
+	layer := TilemapLayerJSON{Type: TileLayer, Name: "ground"}
+	for y := -2; y <= 0; y += 2 {
+		for x := -2; x <= 0; x += 2 {
+			layer.Chunks = append(layer.Chunks, &ChunkJSON{
+				RawData: json.RawMessage(`[1, 2, 3, 4]`),
+				X:       x,
+				Y:       y,
+				Width:   2,
+				Height:  2,
+			})
+		}
+	}
+
+	t := &TilemapJSON{
+		Layers:     []TilemapLayerJSON{layer},
+		TileWidth:  10,
+		TileHeight: 10,
+		Infinite:   true,
+	}
+	t.flatten()
+	return t
+}
+
+func TestChunkStreamerUpdate(t *testing.T) {
+	m := chunkedMap()
+	chunks := m.FlatLayers()[0].Chunks
+	s := NewChunkStreamer(m, 0)
+
+	// only the chunk at (0, 0), 20x20 pixels from the origin
+	err := s.Update(image.Rect(5, 5, 15, 15))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, chunk := range chunks {
+		if chunk.Loaded() != (chunk.X == 0 && chunk.Y == 0) {
+			t.Errorf("chunk (%d, %d) loaded = %v", chunk.X, chunk.Y, chunk.Loaded())
+		}
+	}
+	if len(s.Visible(0)) != 1 {
+		t.Errorf("%d visible chunks, want 1", len(s.Visible(0)))
+	}
+
+	// the view reaches across the origin, every chunk loads
+	err = s.Update(image.Rect(-5, -5, 5, 5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, chunk := range chunks {
+		if !chunk.Loaded() {
+			t.Errorf("chunk (%d, %d) not loaded", chunk.X, chunk.Y)
+		}
+	}
+
+	// far away, everything is dropped
+	err = s.Update(image.Rect(1000, 1000, 1010, 1010))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, chunk := range chunks {
+		if chunk.Loaded() {
+			t.Errorf("chunk (%d, %d) still loaded", chunk.X, chunk.Y)
+		}
+	}
+}
+
+func TestSetTileSurvivesStreaming(t *testing.T) {
+	m := chunkedMap()
+	layer := m.FlatLayers()[0]
+	r, err := NewRenderer(m, NewGidResolver(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	near := image.Rect(5, 5, 15, 15)
+	far := image.Rect(1000, 1000, 1010, 1010)
+
+	err = r.Update(near)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.SetTile(layer, 1, 1, 9|int(FlipVertical)) {
+		t.Fatal("SetTile on a loaded chunk failed")
+	}
+
+	err = r.Update(far)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.SetTile(layer, 0, 0, 9) {
+		t.Error("SetTile on a chunk that isn't loaded succeeded")
+	}
+
+	err = r.Update(near)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tiles, _, index := layer.cell(1, 1)
+	if tiles == nil {
+		t.Fatal("chunk wasn't streamed back in")
+	}
+	if tiles.Data[index] != 9 || tiles.Flip(index) != FlipVertical {
+		t.Errorf("tile after streaming = %d, %#x, want 9, %#x", tiles.Data[index], tiles.Flip(index), FlipVertical)
+	}
+	if layer.gidAt(0, 0) != 1 {
+		t.Errorf("untouched tile after streaming = %d, want 1", layer.gidAt(0, 0))
+	}
+}
@@ -0,0 +1,104 @@
+package tilemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// decodeData turns a layer's "data" field into gids. Tiled writes it either as
+// a JSON array (encoding "csv", the default) or as a base64 string of
+// little-endian uint32s, optionally compressed with zlib, gzip or zstd.
+// Anything but size gids is an error, so broken data fails on load.
+func decodeData(raw json.RawMessage, encoding, compression string, size int) ([]int, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	data, err := decodeGids(raw, encoding, compression)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) != size {
+		return nil, fmt.Errorf("tilemap: layer data has %d tiles, want %d", len(data), size)
+	}
+	return data, nil
+}
+
+func decodeGids(raw json.RawMessage, encoding, compression string) ([]int, error) {
+	switch encoding {
+	case "", "csv":
+		var data []int
+		err := json.Unmarshal(raw, &data)
+		if err != nil {
+			return nil, err
+		}
+		return data, nil
+	case "base64":
+	default:
+		return nil, fmt.Errorf("tilemap: unsupported layer encoding %q", encoding)
+	}
+
+	var encoded string
+	err := json.Unmarshal(raw, &encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, err = decompress(decoded, compression)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(decoded)%4 != 0 {
+		return nil, fmt.Errorf("tilemap: layer data is %d bytes, not a multiple of 4", len(decoded))
+	}
+
+	data := make([]int, len(decoded)/4)
+	for i := range data {
+		data[i] = int(binary.LittleEndian.Uint32(decoded[i*4:]))
+	}
+	return data, nil
+}
+
+func decompress(data []byte, compression string) ([]byte, error) {
+	var reader io.ReadCloser
+	var err error
+
+	switch compression {
+	case "":
+		return data, nil
+	case "zlib":
+		reader, err = zlib.NewReader(bytes.NewReader(data))
+	case "gzip":
+		reader, err = gzip.NewReader(bytes.NewReader(data))
+	case "zstd":
+		var decoder *zstd.Decoder
+		decoder, err = zstd.NewReader(bytes.NewReader(data))
+		if err == nil {
+			reader = decoder.IOReadCloser()
+		}
+	default:
+		return nil, fmt.Errorf("tilemap: unsupported layer compression %q", compression)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
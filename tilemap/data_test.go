@@ -0,0 +1,119 @@
+package tilemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// base64 "data" field of gids, compressed the way Tiled does it.
+func encodeData(t *testing.T, gids []int, compression string) json.RawMessage {
+	t.Helper()
+
+	raw := make([]byte, 0, len(gids)*4)
+	for _, gid := range gids {
+		raw = binary.LittleEndian.AppendUint32(raw, uint32(gid))
+	}
+
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch compression {
+	case "":
+		buf.Write(raw)
+	case "zlib":
+		w = zlib.NewWriter(&buf)
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "zstd":
+		var err error
+		w, err = zstd.NewWriter(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if w != nil {
+		w.Write(raw)
+		w.Close()
+	}
+
+	encoded, err := json.Marshal(base64.StdEncoding.EncodeToString(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encoded
+}
+
+func TestDecodeData(t *testing.T) {
+	// the last gid is flipped horizontally, decoding leaves the flags in
+	gids := []int{0, 1, 2, 300, 0x80000005}
+
+	tests := []struct {
+		name        string
+		raw         json.RawMessage
+		encoding    string
+		compression string
+	}{
+		{"csv default", json.RawMessage(`[0, 1, 2, 300, 2147483653]`), "", ""},
+		{"csv", json.RawMessage(`[0, 1, 2, 300, 2147483653]`), "csv", ""},
+		{"base64", encodeData(t, gids, ""), "base64", ""},
+		{"base64 zlib", encodeData(t, gids, "zlib"), "base64", "zlib"},
+		{"base64 gzip", encodeData(t, gids, "gzip"), "base64", "gzip"},
+		{"base64 zstd", encodeData(t, gids, "zstd"), "base64", "zstd"},
+	}
+
+	for _, test := range tests {
+		data, err := decodeData(test.raw, test.encoding, test.compression, len(gids))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(data, gids) {
+			t.Errorf("%s: decoded %v, want %v", test.name, data, gids)
+		}
+	}
+}
+
+func TestDecodeDataEmpty(t *testing.T) {
+	data, err := decodeData(nil, "base64", "zlib", 100)
+	if data != nil || err != nil {
+		t.Errorf("decodeData(nil) = %v, %v, want nil, nil", data, err)
+	}
+}
+
+func TestDecodeDataErrors(t *testing.T) {
+	gids := []int{1, 2, 3, 4}
+
+	tests := []struct {
+		name        string
+		raw         json.RawMessage
+		encoding    string
+		compression string
+	}{
+		{"unknown encoding", json.RawMessage(`"AQAAAA=="`), "hex", ""},
+		{"unknown compression", encodeData(t, gids, ""), "base64", "lz4"},
+		{"csv not an array", json.RawMessage(`"1,2,3,4"`), "csv", ""},
+		{"base64 not a string", json.RawMessage(`[1, 2, 3, 4]`), "base64", ""},
+		{"bad base64", json.RawMessage(`"not base64!"`), "base64", ""},
+		{"not compressed", encodeData(t, gids, ""), "base64", "zlib"},
+		{"wrong compression", encodeData(t, gids, "gzip"), "base64", "zstd"},
+		{"partial gid", json.RawMessage(`"AQAAAAI="`), "base64", ""},
+		{"csv too short", json.RawMessage(`[1, 2, 3]`), "csv", ""},
+		{"csv too long", json.RawMessage(`[1, 2, 3, 4, 5]`), "csv", ""},
+		{"base64 too short", encodeData(t, gids[:3], "zlib"), "base64", "zlib"},
+	}
+
+	for _, test := range tests {
+		data, err := decodeData(test.raw, test.encoding, test.compression, len(gids))
+		if err == nil {
+			t.Errorf("%s: decoded %v, want an error", test.name, data)
+		}
+	}
+}
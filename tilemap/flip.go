@@ -0,0 +1,79 @@
+package tilemap
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Tiled stores how a tile is flipped in the top bits of its gid.
+type FlipFlags uint32
+
+const (
+	FlipHorizontal FlipFlags = 0x80000000
+	FlipVertical   FlipFlags = 0x40000000
+	FlipDiagonal   FlipFlags = 0x20000000
+	// Only used by hexagonal maps, stripped but otherwise ignored.
+	RotateHex120 FlipFlags = 0x10000000
+
+	flipMask = FlipHorizontal | FlipVertical | FlipDiagonal | RotateHex120
+)
+
+// SplitGid separates a raw gid from layer data into the tile gid and its flip flags.
+func SplitGid(raw int) (int, FlipFlags) {
+	flags := FlipFlags(uint32(raw)) & flipMask
+	gid := int(uint32(raw) &^ uint32(flipMask))
+	return gid, flags
+}
+
+// Apply adds the flip to geom for a tile image of size w x h. The flipped
+// image still starts at (0, 0), so the tile position can be translated after.
+// Like Tiled, the diagonal flip (x/y swap) goes first, then horizontal, then vertical.
+func (f FlipFlags) Apply(geom *ebiten.GeoM, w, h float64) {
+	if f&FlipDiagonal != 0 {
+		var swap ebiten.GeoM
+		swap.SetElement(0, 0, 0)
+		swap.SetElement(0, 1, 1)
+		swap.SetElement(1, 0, 1)
+		swap.SetElement(1, 1, 0)
+		geom.Concat(swap)
+
+		w, h = h, w
+	}
+
+	if f&FlipHorizontal != 0 {
+		geom.Scale(-1, 1)
+		geom.Translate(w, 0)
+	}
+
+	if f&FlipVertical != 0 {
+		geom.Scale(1, -1)
+		geom.Translate(0, h)
+	}
+}
+
+// Rect flips a rectangle inside a tile image of size w x h the same way Apply flips the image.
+func (f FlipFlags) Rect(r image.Rectangle, w, h int) image.Rectangle {
+	if f&FlipDiagonal != 0 {
+		r = image.Rect(r.Min.Y, r.Min.X, r.Max.Y, r.Max.X)
+		w, h = h, w
+	}
+
+	if f&FlipHorizontal != 0 {
+		r = image.Rect(w-r.Max.X, r.Min.Y, w-r.Min.X, r.Max.Y)
+	}
+
+	if f&FlipVertical != 0 {
+		r = image.Rect(r.Min.X, h-r.Max.Y, r.Max.X, h-r.Min.Y)
+	}
+
+	return r
+}
+
+// Size of the tile image once flipped, the diagonal flip swaps width and height.
+func (f FlipFlags) Size(w, h int) (int, int) {
+	if f&FlipDiagonal != 0 {
+		return h, w
+	}
+	return w, h
+}
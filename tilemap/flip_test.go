@@ -0,0 +1,58 @@
+package tilemap
+
+import (
+	"image"
+	"testing"
+)
+
+func TestSplitGid(t *testing.T) {
+	tests := []struct {
+		raw   int
+		gid   int
+		flags FlipFlags
+	}{
+		{raw: 0, gid: 0, flags: 0},
+		{raw: 42, gid: 42, flags: 0},
+		{raw: 0x80000000 | 42, gid: 42, flags: FlipHorizontal},
+		{raw: 0x40000000 | 42, gid: 42, flags: FlipVertical},
+		{raw: 0x20000000 | 42, gid: 42, flags: FlipDiagonal},
+		{raw: 0x10000000 | 42, gid: 42, flags: RotateHex120},
+		{raw: 0xe0000000 | 7, gid: 7, flags: FlipHorizontal | FlipVertical | FlipDiagonal},
+		{raw: 0x0fffffff, gid: 0x0fffffff, flags: 0},
+	}
+
+	for _, test := range tests {
+		gid, flags := SplitGid(test.raw)
+		if gid != test.gid || flags != test.flags {
+			t.Errorf("SplitGid(%#x) = %d, %#x, want %d, %#x", test.raw, gid, flags, test.gid, test.flags)
+		}
+	}
+}
+
+func TestFlipRect(t *testing.T) {
+	// a 2x3 box at the top left of a 10x20 tile
+	r := image.Rect(1, 2, 3, 5)
+
+	tests := []struct {
+		name  string
+		flags FlipFlags
+		want  image.Rectangle
+	}{
+		{"none", 0, image.Rect(1, 2, 3, 5)},
+		{"horizontal", FlipHorizontal, image.Rect(7, 2, 9, 5)},
+		{"vertical", FlipVertical, image.Rect(1, 15, 3, 18)},
+		{"both", FlipHorizontal | FlipVertical, image.Rect(7, 15, 9, 18)},
+		{"diagonal", FlipDiagonal, image.Rect(2, 1, 5, 3)},
+		// rotated 90° clockwise
+		{"diagonal horizontal", FlipDiagonal | FlipHorizontal, image.Rect(15, 1, 18, 3)},
+		// rotated 90° counter-clockwise
+		{"diagonal vertical", FlipDiagonal | FlipVertical, image.Rect(2, 7, 5, 9)},
+	}
+
+	for _, test := range tests {
+		got := test.flags.Rect(r, 10, 20)
+		if got != test.want {
+			t.Errorf("%s: Rect = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
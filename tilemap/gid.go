@@ -0,0 +1,102 @@
+package tilemap
+
+import (
+	"image"
+	"rpg-game-go/objects"
+	"rpg-game-go/properties"
+	"rpg-game-go/tileset"
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// GidResolver maps the global tile ids (gids) stored in layer data to the
+// tileset they belong to. A gid belongs to the tileset with the highest
+// firstgid that is <= gid.
+type GidResolver struct {
+	tilesets []tileset.Tileset // sorted by first gid
+}
+
+func NewGidResolver(tilesets []tileset.Tileset) *GidResolver {
+	sorted := make([]tileset.Tileset, len(tilesets))
+	copy(sorted, tilesets)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].FirstGid() < sorted[j].FirstGid()
+	})
+
+	return &GidResolver{
+		tilesets: sorted,
+	}
+}
+
+// Resolve returns the tileset owning gid and the tile id local to that tileset.
+// The tileset is nil for the empty tile (0) or a gid below every firstgid.
+func (r *GidResolver) Resolve(gid int) (tileset.Tileset, int) {
+	if gid <= 0 {
+		return nil, 0
+	}
+
+	// first tileset that starts after gid, the one before it owns the tile
+	index := sort.Search(len(r.tilesets), func(i int) bool {
+		return r.tilesets[i].FirstGid() > gid
+	})
+	if index == 0 {
+		return nil, 0
+	}
+
+	ts := r.tilesets[index-1]
+	return ts, gid - ts.FirstGid()
+}
+
+func (r *GidResolver) Img(gid int) *ebiten.Image {
+	ts, id := r.Resolve(gid)
+	if ts == nil {
+		return nil
+	}
+	return ts.Img(id)
+}
+
+func (r *GidResolver) Colliders(gid int) []*objects.ObjectJSON {
+	ts, id := r.Resolve(gid)
+	if ts == nil {
+		return nil
+	}
+	return ts.Colliders(id)
+}
+
+func (r *GidResolver) Properties(gid int) properties.Properties {
+	ts, id := r.Resolve(gid)
+	if ts == nil {
+		return nil
+	}
+	return ts.Properties(id)
+}
+
+func (r *GidResolver) TileOffset(gid int) image.Point {
+	ts, _ := r.Resolve(gid)
+	if ts == nil {
+		return image.Point{}
+	}
+	return ts.TileOffset()
+}
+
+func (r *GidResolver) Animated(gid int) bool {
+	ts, id := r.Resolve(gid)
+	return ts != nil && len(ts.Animation(id)) > 0
+}
+
+// Animate returns the gid of the frame an animated tile shows at time ms, or
+// gid itself if the tile isn't animated.
+func (r *GidResolver) Animate(gid, ms int) int {
+	ts, id := r.Resolve(gid)
+	if ts == nil {
+		return gid
+	}
+
+	frames := ts.Animation(id)
+	if len(frames) == 0 {
+		return gid
+	}
+	return ts.FirstGid() + tileset.FrameAt(frames, ms)
+}
@@ -0,0 +1,123 @@
+package tilemap
+
+import (
+	"image"
+	"rpg-game-go/objects"
+	"rpg-game-go/properties"
+	"rpg-game-go/tileset"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// A tileset of one image shared by every tile, the rest comes from maps by
+// tile id.
+type stubTileset struct {
+	firstGid   int
+	img        *ebiten.Image
+	animations map[int][]tileset.FrameJSON
+	colliders  map[int][]*objects.ObjectJSON
+	properties map[int]properties.Properties
+	offset     image.Point
+	alignment  string
+}
+
+func (s *stubTileset) FirstGid() int {
+	return s.firstGid
+}
+
+func (s *stubTileset) Img(id int) *ebiten.Image {
+	return s.img
+}
+
+func (s *stubTileset) Colliders(id int) []*objects.ObjectJSON {
+	return s.colliders[id]
+}
+
+func (s *stubTileset) Animation(id int) []tileset.FrameJSON {
+	return s.animations[id]
+}
+
+func (s *stubTileset) Properties(id int) properties.Properties {
+	return s.properties[id]
+}
+
+func (s *stubTileset) TileOffset() image.Point {
+	return s.offset
+}
+
+func (s *stubTileset) ObjectAlignment() string {
+	return s.alignment
+}
+
+func TestResolve(t *testing.T) {
+	first := &stubTileset{firstGid: 1}
+	second := &stubTileset{firstGid: 50}
+	third := &stubTileset{firstGid: 120}
+	// out of order on purpose, the resolver sorts them
+	resolver := NewGidResolver([]tileset.Tileset{third, first, second})
+
+	tests := []struct {
+		gid     int
+		tileset tileset.Tileset
+		id      int
+	}{
+		{gid: -1, tileset: nil, id: 0},
+		{gid: 0, tileset: nil, id: 0},
+		{gid: 1, tileset: first, id: 0},
+		{gid: 49, tileset: first, id: 48},
+		{gid: 50, tileset: second, id: 0},
+		{gid: 51, tileset: second, id: 1},
+		{gid: 119, tileset: second, id: 69},
+		{gid: 120, tileset: third, id: 0},
+		{gid: 5000, tileset: third, id: 4880},
+	}
+
+	for _, test := range tests {
+		ts, id := resolver.Resolve(test.gid)
+		if ts != test.tileset || id != test.id {
+			t.Errorf("Resolve(%d) = %v, %d, want %v, %d", test.gid, ts, id, test.tileset, test.id)
+		}
+	}
+}
+
+func TestResolveBelowFirstGid(t *testing.T) {
+	resolver := NewGidResolver([]tileset.Tileset{&stubTileset{firstGid: 10}})
+
+	ts, id := resolver.Resolve(9)
+	if ts != nil || id != 0 {
+		t.Errorf("Resolve(9) = %v, %d, want nil, 0", ts, id)
+	}
+}
+
+func TestAnimate(t *testing.T) {
+	water := &stubTileset{
+		firstGid: 10,
+		animations: map[int][]tileset.FrameJSON{
+			// tile 2 loops through tiles 2, 3 and 4, 100ms each
+			2: {{TileId: 2, Duration: 100}, {TileId: 3, Duration: 100}, {TileId: 4, Duration: 100}},
+		},
+	}
+	resolver := NewGidResolver([]tileset.Tileset{&stubTileset{firstGid: 1}, water})
+
+	tests := []struct {
+		gid, ms, want int
+	}{
+		{gid: 0, ms: 50, want: 0},
+		// not animated
+		{gid: 5, ms: 150, want: 5},
+		{gid: 11, ms: 150, want: 11},
+		// animated
+		{gid: 12, ms: 0, want: 12},
+		{gid: 12, ms: 100, want: 13},
+		{gid: 12, ms: 250, want: 14},
+		{gid: 12, ms: 300, want: 12},
+	}
+
+	for _, test := range tests {
+		got := resolver.Animate(test.gid, test.ms)
+		if got != test.want {
+			t.Errorf("Animate(%d, %d) = %d, want %d", test.gid, test.ms, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package tilemap
+
+import (
+	"encoding/json"
+	"rpg-game-go/camera"
+	"rpg-game-go/properties"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Tiled leaves out fields that are at their default, so set those before decoding.
+func (l *TilemapLayerJSON) UnmarshalJSON(data []byte) error {
+	type layerJSON TilemapLayerJSON
+
+	layer := layerJSON{
+		Visible:   true,
+		Opacity:   1.0,
+		ParallaxX: 1.0,
+		ParallaxY: 1.0,
+	}
+	err := json.Unmarshal(data, &layer)
+	if err != nil {
+		return err
+	}
+
+	*l = TilemapLayerJSON(layer)
+	return nil
+}
+
+// YSorted layers, marked with the custom property "ysort", draw their tiles
+// and tile objects through the render queue so sprites can walk behind and in
+// front of them.
+func (l *TilemapLayerJSON) YSorted() bool {
+	return (l.Type == TileLayer || l.Type == ObjectGroup) && l.Properties.Bool("ysort", false)
+}
+
+// IsVisible is false if the layer, or any group it is in, is hidden or fully transparent.
+func (l *TilemapLayerJSON) IsVisible() bool {
+	for layer := l; layer != nil; layer = layer.parent {
+		if !layer.Visible || layer.Opacity <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ColorScale combines the opacity and tint color of the layer and its groups.
+func (l *TilemapLayerJSON) ColorScale() ebiten.ColorScale {
+	var scale ebiten.ColorScale
+
+	for layer := l; layer != nil; layer = layer.parent {
+		if layer.TintColor != "" {
+			tint, err := properties.ParseColor(layer.TintColor)
+			if err == nil {
+				r, g, b, a := float32(tint.R)/255, float32(tint.G)/255, float32(tint.B)/255, float32(tint.A)/255
+				// color scales work on premultiplied alpha
+				scale.Scale(r*a, g*a, b*a, a)
+			}
+		}
+
+		scale.ScaleAlpha(float32(layer.Opacity))
+	}
+	return scale
+}
+
+// WorldOffset is where the layer's origin is in the world, in pixels: the
+// position and offset of the layer and of every group it is in added up.
+// Parallax is left out, see HasParallax.
+func (l *TilemapLayerJSON) WorldOffset(t *TilemapJSON) (float64, float64) {
+	x, y := 0.0, 0.0
+	for layer := l; layer != nil; layer = layer.parent {
+		x += float64(layer.X*t.TileWidth) + layer.OffsetX
+		y += float64(layer.Y*t.TileHeight) + layer.OffsetY
+	}
+	return x, y
+}
+
+// parallax multiplies the parallax factors of the layer and its groups.
+func (l *TilemapLayerJSON) parallax() (float64, float64) {
+	parallaxX, parallaxY := 1.0, 1.0
+	for layer := l; layer != nil; layer = layer.parent {
+		parallaxX *= layer.ParallaxX
+		parallaxY *= layer.ParallaxY
+	}
+	return parallaxX, parallaxY
+}
+
+// HasParallax is true if the layer, or a group it is in, scrolls at another
+// speed than the camera. Such layers have no fixed place in the world, so
+// they get no colliders, portals or tile properties.
+func (l *TilemapLayerJSON) HasParallax() bool {
+	parallaxX, parallaxY := l.parallax()
+	return parallaxX != 1 || parallaxY != 1
+}
+
+// Collides is true if the tiles of the layer block movement. Hidden layers
+// don't, unless the custom property "collides" is set to true. Setting it to
+// false turns the colliders of a visible layer off.
+func (l *TilemapLayerJSON) Collides() bool {
+	if l.Type != TileLayer || l.HasParallax() {
+		return false
+	}
+	return l.Properties.Bool("collides", l.IsVisible())
+}
+
+// ScreenOffset is where the layer's origin ends up on screen, before the
+// camera's lens. Offsets of groups add up and their parallax factors
+// multiply. Layers with a parallax factor below 1 scroll slower than the
+// camera, above 1 faster. Like in Tiled, a layer is where the map puts it when
+// the middle of the view is on the map's parallax origin.
+func (l *TilemapLayerJSON) ScreenOffset(t *TilemapJSON, cam *camera.Camera, screenWidth, screenHeight float64) (float64, float64) {
+	x, y := l.WorldOffset(t)
+	parallaxX, parallaxY := l.parallax()
+
+	// Tiled moves the layer by (view center - parallax origin) * (1 - factor)
+	centerX := -cam.X + screenWidth/2.0
+	centerY := -cam.Y + screenHeight/2.0
+	x += cam.X + (centerX-t.ParallaxOriginX)*(1-parallaxX)
+	y += cam.Y + (centerY-t.ParallaxOriginY)*(1-parallaxY)
+	return x, y
+}
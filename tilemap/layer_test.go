@@ -0,0 +1,82 @@
+package tilemap
+
+import (
+	"rpg-game-go/properties"
+	"testing"
+)
+
+func collidesProperty(value bool) properties.Properties {
+	return properties.Properties{{Name: "collides", Type: properties.Bool, Value: value}}
+}
+
+func TestWorldOffset(t *testing.T) {
+	m := &TilemapJSON{
+		TileWidth:  16,
+		TileHeight: 8,
+		Layers: []TilemapLayerJSON{{
+			Type:      Group,
+			OffsetX:   10,
+			OffsetY:   -4.5,
+			ParallaxX: 1,
+			ParallaxY: 1,
+			Layers: []TilemapLayerJSON{{
+				Type:    Group,
+				X:       1,
+				OffsetY: 2,
+				// parallax doesn't move the layer in the world
+				ParallaxX: 0.5,
+				ParallaxY: 1,
+				Layers: []TilemapLayerJSON{{
+					Type:      TileLayer,
+					X:         2,
+					Y:         3,
+					OffsetX:   0.25,
+					ParallaxX: 1,
+					ParallaxY: 1,
+				}},
+			}},
+		}},
+	}
+	m.flatten()
+
+	x, y := m.FlatLayers()[0].WorldOffset(m)
+	if x != 10+16+32+0.25 || y != -4.5+2+24 {
+		t.Errorf("WorldOffset = %v, %v, want %v, %v", x, y, 10+16+32+0.25, -4.5+2+24)
+	}
+}
+
+func TestCollides(t *testing.T) {
+	tests := []struct {
+		name  string
+		layer TilemapLayerJSON
+		group TilemapLayerJSON
+		want  bool
+	}{
+		{"visible", TilemapLayerJSON{Type: TileLayer, Visible: true, Opacity: 1}, TilemapLayerJSON{Visible: true, Opacity: 1}, true},
+		{"hidden", TilemapLayerJSON{Type: TileLayer, Opacity: 1}, TilemapLayerJSON{Visible: true, Opacity: 1}, false},
+		{"transparent", TilemapLayerJSON{Type: TileLayer, Visible: true}, TilemapLayerJSON{Visible: true, Opacity: 1}, false},
+		{"in a hidden group", TilemapLayerJSON{Type: TileLayer, Visible: true, Opacity: 1}, TilemapLayerJSON{Opacity: 1}, false},
+		{"hidden but collides", TilemapLayerJSON{Type: TileLayer, Opacity: 1, Properties: collidesProperty(true)}, TilemapLayerJSON{Visible: true, Opacity: 1}, true},
+		{"visible but doesn't collide", TilemapLayerJSON{Type: TileLayer, Visible: true, Opacity: 1, Properties: collidesProperty(false)}, TilemapLayerJSON{Visible: true, Opacity: 1}, false},
+		{"parallax", TilemapLayerJSON{Type: TileLayer, Visible: true, Opacity: 1, ParallaxY: 0.5}, TilemapLayerJSON{Visible: true, Opacity: 1}, false},
+		{"in a parallax group", TilemapLayerJSON{Type: TileLayer, Visible: true, Opacity: 1, Properties: collidesProperty(true)}, TilemapLayerJSON{Visible: true, Opacity: 1, ParallaxX: 2}, false},
+		{"object group", TilemapLayerJSON{Type: ObjectGroup, Visible: true, Opacity: 1}, TilemapLayerJSON{Visible: true, Opacity: 1}, false},
+	}
+
+	for _, test := range tests {
+		for _, layer := range []*TilemapLayerJSON{&test.layer, &test.group} {
+			if layer.ParallaxX == 0 {
+				layer.ParallaxX = 1
+			}
+			if layer.ParallaxY == 0 {
+				layer.ParallaxY = 1
+			}
+		}
+		test.layer.parent = &test.group
+
+		got := test.layer.Collides()
+		if got != test.want {
+			t.Errorf("%s: Collides = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
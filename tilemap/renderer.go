@@ -0,0 +1,288 @@
+package tilemap
+
+import (
+	"image"
+	"math"
+	"rpg-game-go/camera"
+	"rpg-game-go/render"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// Renderer draws the tile and image layers of a map. Tile layers are baked
+// into blocks of tiles, and only the blocks on screen are drawn. For infinite
+// maps it streams the chunks around the camera in and out.
+type Renderer struct {
+	tilemap     *TilemapJSON
+	gids        *GidResolver
+	chunks      *ChunkStreamer // nil for finite maps
+	images      map[*TilemapLayerJSON]*ebiten.Image
+	blocks      map[*TilemapLayerJSON][]*tileBlock // finite tile layers
+	chunkBlocks map[*ChunkJSON]*tileBlock          // loaded chunks of infinite maps
+	// tile colliders of the loaded chunks, by chunk and all together
+	chunkColliders map[*ChunkJSON][]image.Rectangle
+	colliders      []image.Rectangle
+	// milliseconds since the map was loaded, shared by all animated tiles so they stay in sync
+	clock float64
+}
+
+func NewRenderer(t *TilemapJSON, gids *GidResolver) (*Renderer, error) {
+	r := &Renderer{
+		tilemap:     t,
+		gids:        gids,
+		chunks:      nil,
+		images:      make(map[*TilemapLayerJSON]*ebiten.Image),
+		blocks:      make(map[*TilemapLayerJSON][]*tileBlock),
+		chunkBlocks: make(map[*ChunkJSON]*tileBlock),
+		clock:       0,
+
+		chunkColliders: make(map[*ChunkJSON][]image.Rectangle),
+		colliders:      make([]image.Rectangle, 0),
+	}
+
+	if t.Infinite {
+		r.chunks = NewChunkStreamer(t, 1)
+	}
+
+	for _, layer := range t.FlatLayers() {
+		if layer.Type == TileLayer && layer.Chunks == nil {
+			r.blocks[layer] = newLayerBlocks(layer)
+		}
+		if layer.Type != ImageLayer || layer.Image == "" {
+			continue
+		}
+
+		img, _, err := ebitenutil.NewImageFromFile(t.ImagePath(layer))
+		if err != nil {
+			return nil, err
+		}
+		r.images[layer] = img
+	}
+	return r, nil
+}
+
+// Deallocate frees the baked blocks and layer images, once the map isn't drawn anymore.
+func (r *Renderer) Deallocate() {
+	for _, blocks := range r.blocks {
+		for _, block := range blocks {
+			block.deallocate()
+		}
+	}
+	for _, block := range r.chunkBlocks {
+		block.deallocate()
+	}
+	for _, img := range r.images {
+		img.Deallocate()
+	}
+}
+
+// Update advances tile animations and streams chunks for the part of the world that is on screen.
+func (r *Renderer) Update(view image.Rectangle) error {
+	r.clock += 1000 / float64(ebiten.TPS())
+
+	if r.chunks == nil {
+		return nil
+	}
+
+	err := r.chunks.Update(view)
+	if err != nil {
+		return err
+	}
+
+	// baked chunks go with the chunks that were streamed out
+	for chunk, block := range r.chunkBlocks {
+		if !chunk.Loaded() {
+			block.deallocate()
+			delete(r.chunkBlocks, chunk)
+		}
+	}
+
+	r.updateColliders()
+	return nil
+}
+
+// updateColliders builds the colliders of chunks that were streamed in and
+// drops those of chunks that were streamed out.
+func (r *Renderer) updateColliders() {
+	changed := false
+	for chunk := range r.chunkColliders {
+		if !chunk.Loaded() {
+			delete(r.chunkColliders, chunk)
+			changed = true
+		}
+	}
+	for key, chunk := range r.chunks.loaded {
+		_, exists := r.chunkColliders[chunk]
+		if !exists {
+			layer := r.tilemap.FlatLayers()[key.layer]
+			r.chunkColliders[chunk] = r.tilemap.ChunkColliders(r.gids, layer, chunk)
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+
+	r.colliders = r.colliders[:0]
+	for _, colliders := range r.chunkColliders {
+		r.colliders = append(r.colliders, colliders...)
+	}
+}
+
+// Colliders returns the tile colliders of the chunks of an infinite map that
+// are streamed in. They change as the camera moves, so ask again after every
+// Update. Finite maps have none, their tiles are in TileColliders.
+func (r *Renderer) Colliders() []image.Rectangle {
+	return r.colliders
+}
+
+// SetTile puts the tile gid, with any flip flags, at (col, row) of a tile
+// layer and rebakes the block it is in before the next draw. Edits to chunks
+// of infinite maps survive the chunk being streamed out. It returns false
+// if the position is outside the layer or in a chunk that isn't loaded.
+func (r *Renderer) SetTile(layer *TilemapLayerJSON, col, row, gid int) bool {
+	tiles, chunk, index := layer.cell(col, row)
+	if tiles == nil {
+		return false
+	}
+	tiles.set(index, gid)
+
+	if chunk != nil {
+		chunk.edited = true
+		block, exists := r.chunkBlocks[chunk]
+		if exists {
+			block.dirty = true
+		}
+		return true
+	}
+
+	layerBlock(r.blocks[layer], layer, col-layer.X, row-layer.Y).dirty = true
+	return true
+}
+
+// Draw draws the map as seen from cam, culling the blocks of tiles that are
+// off screen. The sprites already in queue are drawn in depth order together
+// with the tiles of the first y-sorted layer, or on top of the map if it has
+// no such layer.
+func (r *Renderer) Draw(screen *ebiten.Image, cam *camera.Camera, culler *camera.Culler, queue *render.Queue) {
+	screenW := float64(screen.Bounds().Dx())
+	screenH := float64(screen.Bounds().Dy())
+	lens := cam.Lens(screenW, screenH)
+
+	// what lands on screen once the lens is applied, before it
+	area := cam.View(screenW, screenH).Add(image.Pt(int(cam.X), int(cam.Y))).Inset(-1)
+
+	for layerIndex, layer := range r.tilemap.FlatLayers() {
+		if !layer.IsVisible() {
+			continue
+		}
+
+		op := &ebiten.DrawImageOptions{}
+		op.ColorScale = layer.ColorScale()
+		offsetX, offsetY := layer.ScreenOffset(r.tilemap, cam, screenW, screenH)
+
+		if layer.Type == ImageLayer {
+			r.drawImageLayer(screen, op, offsetX, offsetY, layer, area, lens)
+			continue
+		}
+		if layer.Type != TileLayer && layer.Type != ObjectGroup {
+			continue
+		}
+
+		d := &layerDraw{
+			screen:  screen,
+			op:      op,
+			offsetX: offsetX,
+			offsetY: offsetY,
+			toWorld: image.Pt(int(math.Floor(offsetX-cam.X)), int(math.Floor(offsetY-cam.Y))),
+			lens:    lens,
+			culler:  culler,
+			queue:   queue,
+			sorted:  layer.YSorted(),
+		}
+
+		if layer.Type == ObjectGroup {
+			r.drawObjects(d, layer)
+		} else if r.chunks == nil {
+			for _, block := range r.blocks[layer] {
+				r.drawBlock(d, block)
+			}
+		} else {
+			for _, chunk := range r.chunks.Visible(layerIndex) {
+				block, exists := r.chunkBlocks[chunk]
+				if !exists {
+					block = newChunkBlock(chunk, layer)
+					r.chunkBlocks[chunk] = block
+				}
+				r.drawBlock(d, block)
+			}
+		}
+
+		if layer.YSorted() {
+			queue.Draw(screen)
+		}
+	}
+
+	// nothing left if a y-sorted layer already drew the queue
+	queue.Draw(screen)
+}
+
+// drawObjects draws the tile objects of an object layer. Other shapes are
+// only used for collisions and spawning, so they aren't drawn.
+func (r *Renderer) drawObjects(d *layerDraw, layer *TilemapLayerJSON) {
+	for _, obj := range layer.Objects {
+		if obj.Gid == 0 || !obj.Visible {
+			continue
+		}
+
+		gid, flip := SplitGid(obj.Gid)
+		img := r.gids.Img(r.gids.Animate(gid, int(r.clock)))
+		if img == nil {
+			continue
+		}
+
+		geom := r.objectGeoM(obj, gid, img, flip)
+		d.draw(img, geom, objectRect(geom, img))
+	}
+}
+
+// drawImageLayer draws the layer's image at its offset, repeated to fill area
+// along the axes it repeats on. area and the offset are before the lens.
+func (r *Renderer) drawImageLayer(screen *ebiten.Image, op *ebiten.DrawImageOptions, offsetX, offsetY float64, layer *TilemapLayerJSON, area image.Rectangle, lens ebiten.GeoM) {
+	img, exists := r.images[layer]
+	if !exists {
+		return
+	}
+
+	w := float64(img.Bounds().Dx())
+	h := float64(img.Bounds().Dy())
+
+	// first copy that reaches into the area, and where to stop
+	startX, endX := offsetX, offsetX+w
+	if layer.RepeatX {
+		startX = float64(area.Min.X) + math.Mod(offsetX-float64(area.Min.X), w)
+		if startX > float64(area.Min.X) {
+			startX -= w
+		}
+		endX = float64(area.Max.X)
+	}
+
+	startY, endY := offsetY, offsetY+h
+	if layer.RepeatY {
+		startY = float64(area.Min.Y) + math.Mod(offsetY-float64(area.Min.Y), h)
+		if startY > float64(area.Min.Y) {
+			startY -= h
+		}
+		endY = float64(area.Max.Y)
+	}
+
+	for y := startY; y < endY; y += h {
+		for x := startX; x < endX; x += w {
+			op.GeoM.Reset()
+			op.GeoM.Translate(x, y)
+			op.GeoM.Concat(lens)
+			screen.DrawImage(img, op)
+		}
+	}
+}
@@ -2,49 +2,432 @@ package tilemap
 
 import (
 	"encoding/json"
+	"fmt"
+	"image"
+	"math"
 	"os"
 	"path"
+	"rpg-game-go/objects"
+	"rpg-game-go/properties"
 	"rpg-game-go/tileset"
 )
 
+const (
+	TileLayer   = "tilelayer"
+	ObjectGroup = "objectgroup"
+	ImageLayer  = "imagelayer"
+	Group       = "group"
+)
+
+// Decoded gids of a layer or chunk.
+type TileData struct {
+	Data []int `json:"-"`
+
+	// Flip flags stripped from Data, nil if no tile is flipped.
+	Flips []FlipFlags `json:"-"`
+}
+
+// Flip returns the flip flags of the tile at index in Data.
+func (d *TileData) Flip(index int) FlipFlags {
+	if d.Flips == nil {
+		return 0
+	}
+	return d.Flips[index]
+}
+
+func (d *TileData) decode(raw json.RawMessage, encoding, compression string, size int) error {
+	data, err := decodeData(raw, encoding, compression, size)
+	if err != nil {
+		return err
+	}
+
+	d.Data = data
+	d.Flips = nil
+	d.stripFlips()
+	return nil
+}
+
+// set replaces the tile at index with a gid that may carry flip flags.
+func (d *TileData) set(index, raw int) {
+	gid, flags := SplitGid(raw)
+	d.Data[index] = gid
+
+	if flags != 0 && d.Flips == nil {
+		d.Flips = make([]FlipFlags, len(d.Data))
+	}
+	if d.Flips != nil {
+		d.Flips[index] = flags
+	}
+}
+
+// raw returns the gids with their flip flags put back, the way Tiled stores them.
+func (d *TileData) raw() []int {
+	raw := make([]int, len(d.Data))
+	for index, gid := range d.Data {
+		raw[index] = gid | int(d.Flip(index))
+	}
+	return raw
+}
+
+func (d *TileData) stripFlips() {
+	for index, raw := range d.Data {
+		gid, flags := SplitGid(raw)
+		if flags == 0 {
+			continue
+		}
+
+		if d.Flips == nil {
+			d.Flips = make([]FlipFlags, len(d.Data))
+		}
+		d.Data[index] = gid
+		d.Flips[index] = flags
+	}
+}
+
 type TilemapLayerJSON struct {
-	Data   []int  `json:"data"`
-	Width  int    `json:"width"`
-	Height int    `json:"height"`
-	Name   string `json: "name"`
+	TileData
+	RawData     json.RawMessage       `json:"data"`
+	Chunks      []*ChunkJSON          `json:"chunks"` // only in infinite maps, instead of data
+	Encoding    string                `json:"encoding"`
+	Compression string                `json:"compression"`
+	Width       int                   `json:"width"`
+	Height      int                   `json:"height"`
+	Name        string                `json:"name"`
+	Type        string                `json:"type"`
+	Objects     []*objects.ObjectJSON `json:"objects"`
+	Layers      []TilemapLayerJSON    `json:"layers"` // children of a group
+	Image       string                `json:"image"`  // relative to the map file
+	RepeatX     bool                  `json:"repeatx"`
+	RepeatY     bool                  `json:"repeaty"`
+
+	Visible   bool    `json:"visible"`
+	Opacity   float64 `json:"opacity"`
+	X         int     `json:"x"` // in tiles
+	Y         int     `json:"y"`
+	OffsetX   float64 `json:"offsetx"` // in pixels
+	OffsetY   float64 `json:"offsety"`
+	TintColor string  `json:"tintcolor"`
+	ParallaxX float64 `json:"parallaxx"`
+	ParallaxY float64 `json:"parallaxy"`
+
+	Properties properties.Properties `json:"properties"`
+
+	parent *TilemapLayerJSON // group the layer is in, nil at the top level
+}
+
+// An entry of the map's "tilesets". Either Source points at a tileset file, or
+// the tileset is embedded and its definition is filled in directly.
+type TilesetRefJSON struct {
+	tileset.TilesetJSON
+	FirstGid int    `json:"firstgid"`
+	Source   string `json:"source"`
 }
 
 type TilemapJSON struct {
-	Layers   []TilemapLayerJSON `json:"layers"`
-	Tilesets []map[string]any   `json: "tilesets"`
+	Layers     []TilemapLayerJSON `json:"layers"`
+	Tilesets   []TilesetRefJSON   `json:"tilesets"`
+	Width      int                `json:"width"`
+	Height     int                `json:"height"`
+	TileWidth  int                `json:"tilewidth"`
+	TileHeight int                `json:"tileheight"`
+	Infinite   bool               `json:"infinite"`
+
+	// Point of the map where parallax layers line up with the rest, in pixels.
+	ParallaxOriginX float64 `json:"parallaxoriginx"`
+	ParallaxOriginY float64 `json:"parallaxoriginy"`
+
+	Properties properties.Properties `json:"properties"`
+
+	dir  string              // directory of the map file
+	flat []*TilemapLayerJSON // see FlatLayers
+}
+
+// FlatLayers returns every tile, object and image layer in draw order, with
+// the groups they were in flattened away. Each layer still inherits its
+// groups' visibility, opacity, tint, offset and parallax.
+func (t *TilemapJSON) FlatLayers() []*TilemapLayerJSON {
+	return t.flat
+}
+
+func (t *TilemapJSON) flatten() {
+	t.flat = make([]*TilemapLayerJSON, 0, len(t.Layers))
+
+	var walk func(layers []TilemapLayerJSON, parent *TilemapLayerJSON)
+	walk = func(layers []TilemapLayerJSON, parent *TilemapLayerJSON) {
+		for i := range layers {
+			layer := &layers[i]
+			layer.parent = parent
+
+			if layer.Type == Group {
+				walk(layer.Layers, layer)
+				continue
+			}
+			t.flat = append(t.flat, layer)
+		}
+	}
+	walk(t.Layers, nil)
+}
+
+// Bounds of the map in pixels. Infinite maps can grow in any direction, so
+// theirs cover every chunk of every tile layer instead.
+func (t *TilemapJSON) Bounds() image.Rectangle {
+	if !t.Infinite {
+		return image.Rect(0, 0, t.Width*t.TileWidth, t.Height*t.TileHeight)
+	}
+
+	bounds := image.Rectangle{}
+	for _, layer := range t.FlatLayers() {
+		for _, chunk := range layer.Chunks {
+			bounds = bounds.Union(image.Rect(
+				chunk.X*t.TileWidth,
+				chunk.Y*t.TileHeight,
+				(chunk.X+chunk.Width)*t.TileWidth,
+				(chunk.Y+chunk.Height)*t.TileHeight,
+			))
+		}
+	}
+	return bounds
+}
+
+// ObjectLayer returns the object group with the given name, or nil if the map has none.
+func (t *TilemapJSON) ObjectLayer(name string) *TilemapLayerJSON {
+	for _, layer := range t.FlatLayers() {
+		if layer.Type == ObjectGroup && layer.Name == name {
+			return layer
+		}
+	}
+	return nil
+}
+
+// Colliders builds collision rectangles from the shapes in the named object
+// layer, moved by the layer's offset. Layers with parallax have none.
+func (t *TilemapJSON) Colliders(layerName string) []image.Rectangle {
+	layer := t.ObjectLayer(layerName)
+	if layer == nil || layer.HasParallax() {
+		return make([]image.Rectangle, 0)
+	}
+	x, y := layer.WorldOffset(t)
+	return moveRects(objects.Colliders(layer.Objects), x, y)
+}
+
+// TileColliders places the collision shapes of every tile in the colliding tile layers into world space,
+// with the tiles anchored the same way the renderer draws them. See TilemapLayerJSON.Collides.
+// Chunks of infinite maps are streamed in and out, see ChunkColliders for those.
+func (t *TilemapJSON) TileColliders(gids *GidResolver) []image.Rectangle {
+	colliders := make([]image.Rectangle, 0)
+
+	for _, layer := range t.FlatLayers() {
+		if !layer.Collides() {
+			continue
+		}
+		x, y := layer.WorldOffset(t)
+		rects := t.tileColliders(gids, &layer.TileData, layer.Width, image.Pt(0, 0))
+		colliders = append(colliders, moveRects(rects, x, y)...)
+	}
+	return colliders
+}
+
+// ChunkColliders places the collision shapes of the tiles in a loaded chunk
+// of layer into world space, none if the layer doesn't collide.
+func (t *TilemapJSON) ChunkColliders(gids *GidResolver, layer *TilemapLayerJSON, chunk *ChunkJSON) []image.Rectangle {
+	if !layer.Collides() {
+		return make([]image.Rectangle, 0)
+	}
+	x, y := layer.WorldOffset(t)
+	rects := t.tileColliders(gids, &chunk.TileData, chunk.Width, image.Pt(chunk.X, chunk.Y))
+	return moveRects(rects, x, y)
+}
+
+// moveRects moves rects, in place, by an offset in pixels rounded to whole ones.
+func moveRects(rects []image.Rectangle, x, y float64) []image.Rectangle {
+	offset := image.Pt(int(math.Round(x)), int(math.Round(y)))
+	for i := range rects {
+		rects[i] = rects[i].Add(offset)
+	}
+	return rects
+}
+
+// tileColliders does the work of TileColliders for rows of stride tiles,
+// the first of them at origin in tiles.
+func (t *TilemapJSON) tileColliders(gids *GidResolver, tiles *TileData, stride int, origin image.Point) []image.Rectangle {
+	colliders := make([]image.Rectangle, 0)
+
+	for index, id := range tiles.Data {
+		if id == 0 {
+			continue
+		}
+
+		objs := gids.Colliders(id)
+		if len(objs) == 0 {
+			continue
+		}
+
+		img := gids.Img(id)
+		if img == nil {
+			continue
+		}
+
+		flip := tiles.Flip(index)
+		w, h := img.Bounds().Dx(), img.Bounds().Dy()
+		flippedW, flippedH := flip.Size(w, h)
+		cell := t.cellRect(origin.X+index%stride, origin.Y+index/stride, flippedW, flippedH, gids.TileOffset(id))
+
+		for _, rect := range objects.Colliders(objs) {
+			colliders = append(colliders, flip.Rect(rect, w, h).Add(cell.Min))
+		}
+	}
+	return colliders
+}
+
+// TileProperties returns the custom properties of every tile at (x, y), in
+// world pixels, topmost layer first, so Get finds the value of the tile drawn
+// on top. Only tiles in the cell itself count, not tall tiles reaching into it.
+// Chunks that aren't streamed in and layers with parallax are skipped.
+func (t *TilemapJSON) TileProperties(gids *GidResolver, x, y int) properties.Properties {
+	props := make(properties.Properties, 0)
+
+	layers := t.FlatLayers()
+	for i := len(layers) - 1; i >= 0; i-- {
+		layer := layers[i]
+		if layer.Type != TileLayer || layer.HasParallax() {
+			continue
+		}
+
+		// gidAt counts tiles from the map's origin, so leave the layer's own
+		// position in
+		offsetX, offsetY := layer.WorldOffset(t)
+		col := floorDiv(x-int(math.Round(offsetX)), t.TileWidth) + layer.X
+		row := floorDiv(y-int(math.Round(offsetY)), t.TileHeight) + layer.Y
+
+		id := layer.gidAt(col, row)
+		if id == 0 {
+			continue
+		}
+		props = append(props, gids.Properties(id)...)
+	}
+	return props
+}
+
+// gidAt returns the gid at a tile position of the layer, 0 if it is empty or not loaded.
+func (l *TilemapLayerJSON) gidAt(col, row int) int {
+	tiles, _, index := l.cell(col, row)
+	if tiles == nil {
+		return 0
+	}
+	return tiles.Data[index]
+}
+
+// cell finds where the tile at a tile position of the layer is stored: the
+// data holding it, the chunk that data belongs to in infinite maps, and its
+// index in the data. The data is nil outside the layer and in chunks that
+// aren't loaded.
+func (l *TilemapLayerJSON) cell(col, row int) (*TileData, *ChunkJSON, int) {
+	if l.Chunks == nil {
+		col -= l.X
+		row -= l.Y
+		if col < 0 || row < 0 || col >= l.Width || row >= l.Height || row*l.Width+col >= len(l.Data) {
+			return nil, nil, 0
+		}
+		return &l.TileData, nil, row*l.Width + col
+	}
+
+	for _, chunk := range l.Chunks {
+		x, y := col-chunk.X, row-chunk.Y
+		if x < 0 || y < 0 || x >= chunk.Width || y >= chunk.Height {
+			continue
+		}
+		if !chunk.Loaded() {
+			return nil, nil, 0
+		}
+		return &chunk.TileData, chunk, y*chunk.Width + x
+	}
+	return nil, nil, 0
 }
 
 func (t *TilemapJSON) GenTilesets() ([]tileset.Tileset, error) {
 	tilesets := make([]tileset.Tileset, 0)
 
-	for _, tilesetData := range t.Tilesets {
-		tilesetPath := path.Join("assets/maps/", tilesetData["source"].(string))
+	for index := range t.Tilesets {
+		ref := &t.Tilesets[index]
+		if ref.FirstGid <= 0 {
+			return nil, fmt.Errorf("tilemap: tileset %d has firstgid %d, it must be at least 1", index, ref.FirstGid)
+		}
 
-		tileset, err := tileset.NewTileset(tilesetPath, int(tilesetData["firstgid"].(float64)))
+		var ts tileset.Tileset
+		var err error
+		if ref.Source != "" {
+			ts, err = tileset.NewTileset(path.Join(t.dir, ref.Source), ref.FirstGid)
+		} else {
+			ts, err = tileset.NewTilesetFromJSON(&ref.TilesetJSON, ref.FirstGid, t.dir)
+		}
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("tilemap: tileset %d: %w", index, err)
 		}
-		tilesets = append(tilesets, tileset)
+		tilesets = append(tilesets, ts)
 	}
 	return tilesets, nil
 }
 
-func NewTilemapJSON(filepath string) (*TilemapJSON, error) {
+// NewTilemap loads a map exported from Tiled as JSON (.tmj) or XML (.tmx).
+func NewTilemap(filepath string) (*TilemapJSON, error) {
 	contents, err := os.ReadFile(filepath)
 	if err != nil {
 		return nil, err
 	}
 
-	var tilemapJSON TilemapJSON
-	err = json.Unmarshal(contents, &tilemapJSON)
+	var tilemapJSON *TilemapJSON
+	if isTMX(filepath, contents) {
+		tilemapJSON, err = parseTMX(contents)
+	} else {
+		tilemapJSON, err = parseTMJ(contents)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tilemapJSON.dir = path.Dir(filepath)
+	tilemapJSON.flatten()
+
+	err = tilemapJSON.decodeLayers()
 	if err != nil {
 		return nil, err
 	}
 
+	return tilemapJSON, nil
+}
+
+func parseTMJ(contents []byte) (*TilemapJSON, error) {
+	var tilemapJSON TilemapJSON
+	err := json.Unmarshal(contents, &tilemapJSON)
+	if err != nil {
+		return nil, err
+	}
 	return &tilemapJSON, nil
 }
+
+func (t *TilemapJSON) decodeLayers() error {
+	for _, layer := range t.FlatLayers() {
+		// chunks stay encoded until a ChunkStreamer needs them
+		for _, chunk := range layer.Chunks {
+			chunk.encoding = layer.Encoding
+			chunk.compression = layer.Compression
+		}
+
+		err := layer.decode(layer.RawData, layer.Encoding, layer.Compression, layer.Width*layer.Height)
+		if err != nil {
+			return fmt.Errorf("tilemap: layer %q: %w", layer.Name, err)
+		}
+		layer.RawData = nil
+	}
+	return nil
+}
+
+// ImagePath is where the image of an image layer is on disk.
+func (t *TilemapJSON) ImagePath(layer *TilemapLayerJSON) string {
+	return t.FilePath(layer.Image)
+}
+
+// FilePath is where a file the map refers to, such as a file property, is on disk.
+func (t *TilemapJSON) FilePath(name string) string {
+	return path.Join(t.dir, name)
+}
@@ -0,0 +1,124 @@
+package tilemap
+
+import (
+	"image"
+	"reflect"
+	"rpg-game-go/objects"
+	"rpg-game-go/properties"
+	"rpg-game-go/tileset"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// A map of 16x16 tiles where gid 1 is a wall with a collider over its bottom
+// half and the property "wall". The walls layer is in a group moved by
+// (5, -3) and starts one tile in, so its tile covers (21, -3)-(37, 13).
+func offsetMap() (*TilemapJSON, *GidResolver) {
+	gids := NewGidResolver([]tileset.Tileset{&stubTileset{
+		firstGid: 1,
+		img:      ebiten.NewImage(16, 16),
+		colliders: map[int][]*objects.ObjectJSON{
+			0: {{X: 0, Y: 8, Width: 16, Height: 8}},
+		},
+		properties: map[int]properties.Properties{
+			0: {{Name: "wall", Type: properties.Bool, Value: true}},
+		},
+	}})
+
+	layer := func(layer TilemapLayerJSON) TilemapLayerJSON {
+		layer.Opacity = 1
+		if layer.ParallaxX == 0 {
+			layer.ParallaxX = 1
+		}
+		layer.ParallaxY = 1
+		return layer
+	}
+
+	m := &TilemapJSON{
+		Width:      4,
+		Height:     2,
+		TileWidth:  16,
+		TileHeight: 16,
+		Layers: []TilemapLayerJSON{
+			layer(TilemapLayerJSON{
+				Type: Group, Name: "town", Visible: true, OffsetX: 5, OffsetY: -3,
+				Layers: []TilemapLayerJSON{
+					layer(TilemapLayerJSON{Type: TileLayer, Name: "walls", Visible: true, X: 1, Width: 2, Height: 1, TileData: TileData{Data: []int{1, 0}}}),
+				},
+			}),
+			layer(TilemapLayerJSON{Type: TileLayer, Name: "hidden", X: 3, Width: 1, Height: 1, TileData: TileData{Data: []int{1}}}),
+			layer(TilemapLayerJSON{Type: TileLayer, Name: "far", Visible: true, ParallaxX: 0.5, Width: 1, Height: 1, TileData: TileData{Data: []int{1}}}),
+			layer(TilemapLayerJSON{
+				Type: ObjectGroup, Name: "collision", OffsetX: 2.4, OffsetY: 7.6,
+				Objects: []*objects.ObjectJSON{{X: 0, Y: 0, Width: 10, Height: 10}},
+			}),
+		},
+	}
+	m.flatten()
+	return m, gids
+}
+
+func TestTileCollidersOffset(t *testing.T) {
+	m, gids := offsetMap()
+
+	// the hidden and the parallax layer have none
+	want := []image.Rectangle{image.Rect(21, 5, 37, 13)}
+	got := m.TileColliders(gids)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TileColliders = %v, want %v", got, want)
+	}
+}
+
+func TestChunkCollidersOffset(t *testing.T) {
+	m, gids := offsetMap()
+	layer := m.FlatLayers()[0]
+	chunk := &ChunkJSON{X: -2, Y: 1, Width: 2, Height: 1, TileData: TileData{Data: []int{0, 1}}}
+
+	want := []image.Rectangle{image.Rect(5, 21, 21, 29)}
+	got := m.ChunkColliders(gids, layer, chunk)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ChunkColliders = %v, want %v", got, want)
+	}
+
+	got = m.ChunkColliders(gids, m.FlatLayers()[1], chunk)
+	if len(got) != 0 {
+		t.Errorf("ChunkColliders of a hidden layer = %v, want none", got)
+	}
+}
+
+func TestCollidersOffset(t *testing.T) {
+	m, _ := offsetMap()
+
+	// hidden object layers still collide, offsets round to whole pixels
+	want := []image.Rectangle{image.Rect(2, 8, 12, 18)}
+	got := m.Colliders("collision")
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Colliders = %v, want %v", got, want)
+	}
+}
+
+func TestTilePropertiesOffset(t *testing.T) {
+	m, gids := offsetMap()
+
+	tests := []struct {
+		name string
+		x, y int
+		want bool
+	}{
+		{"top left of the wall", 21, -3, true},
+		{"bottom right of the wall", 36, 12, true},
+		{"left of the wall", 20, 0, false},
+		{"right of the wall", 37, 0, false},
+		{"below the wall", 30, 13, false},
+		// where the wall would be without the offsets, and the parallax layer's tile
+		{"unmoved", 5, 5, false},
+	}
+
+	for _, test := range tests {
+		got := m.TileProperties(gids, test.x, test.y).Bool("wall", false)
+		if got != test.want {
+			t.Errorf("%s: wall at (%d, %d) = %v, want %v", test.name, test.x, test.y, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package tilemap
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"path/filepath"
+	"rpg-game-go/objects"
+	"rpg-game-go/properties"
+	"rpg-game-go/tileset"
+	"strconv"
+	"strings"
+)
+
+// XML (.tmx) version of a map, converted to TilemapJSON on load.
+
+type TileXML struct {
+	Gid uint32 `xml:"gid,attr"`
+}
+
+type ChunkXML struct {
+	X      int       `xml:"x,attr"`
+	Y      int       `xml:"y,attr"`
+	Width  int       `xml:"width,attr"`
+	Height int       `xml:"height,attr"`
+	Text   string    `xml:",chardata"`
+	Tiles  []TileXML `xml:"tile"`
+}
+
+type DataXML struct {
+	Encoding    string     `xml:"encoding,attr"`
+	Compression string     `xml:"compression,attr"`
+	Text        string     `xml:",chardata"`
+	Tiles       []TileXML  `xml:"tile"` // only used when there is no encoding
+	Chunks      []ChunkXML `xml:"chunk"`
+}
+
+// Every kind of layer shares one type so their order in the file is kept.
+type LayerXML struct {
+	XMLName xml.Name
+	Name    string               `xml:"name,attr"`
+	Width   int                  `xml:"width,attr"`
+	Height  int                  `xml:"height,attr"`
+	X       int                  `xml:"x,attr"` // in tiles
+	Y       int                  `xml:"y,attr"`
+	Data    *DataXML             `xml:"data"`
+	Objects []*objects.ObjectXML `xml:"object"`
+	Layers  []LayerXML           `xml:",any"` // children of a group
+	Image   *tileset.ImageXML    `xml:"image"`
+	RepeatX int                  `xml:"repeatx,attr"`
+	RepeatY int                  `xml:"repeaty,attr"`
+
+	Properties []properties.PropertyXML `xml:"properties>property"`
+
+	// strings, so attributes that aren't written keep Tiled's defaults
+	Visible   string  `xml:"visible,attr"`
+	Opacity   string  `xml:"opacity,attr"`
+	OffsetX   float64 `xml:"offsetx,attr"`
+	OffsetY   float64 `xml:"offsety,attr"`
+	TintColor string  `xml:"tintcolor,attr"`
+	ParallaxX string  `xml:"parallaxx,attr"`
+	ParallaxY string  `xml:"parallaxy,attr"`
+}
+
+func attrFloat(attr string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(attr, 64)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// A tileset reference, or an embedded tileset when Source is empty.
+type TilesetRefXML struct {
+	tileset.TilesetXML
+	FirstGid int    `xml:"firstgid,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+type TilemapXML struct {
+	ParallaxOriginX float64 `xml:"parallaxoriginx,attr"`
+	ParallaxOriginY float64 `xml:"parallaxoriginy,attr"`
+
+	Width      int             `xml:"width,attr"`
+	Height     int             `xml:"height,attr"`
+	Infinite   int             `xml:"infinite,attr"`
+	TileWidth  int             `xml:"tilewidth,attr"`
+	TileHeight int             `xml:"tileheight,attr"`
+	Tilesets   []TilesetRefXML `xml:"tileset"`
+	Layers     []LayerXML      `xml:",any"`
+
+	Properties []properties.PropertyXML `xml:"properties>property"`
+}
+
+// Re-encodes layer or chunk data as the JSON "data" field so it goes through decodeData like a .tmj.
+func rawJSON(encoding, text string, tiles []TileXML) (json.RawMessage, error) {
+	switch encoding {
+	case "csv":
+		return json.RawMessage("[" + strings.TrimSpace(text) + "]"), nil
+	case "":
+		gids := make([]int, 0, len(tiles))
+		for _, tile := range tiles {
+			gids = append(gids, int(tile.Gid))
+		}
+		return json.Marshal(gids)
+	default:
+		return json.Marshal(strings.TrimSpace(text))
+	}
+}
+
+func (l *LayerXML) JSON() (TilemapLayerJSON, error) {
+	layerJSON := TilemapLayerJSON{
+		Name:      l.Name,
+		Width:     l.Width,
+		Height:    l.Height,
+		X:         l.X,
+		Y:         l.Y,
+		Visible:   l.Visible != "0",
+		Opacity:   attrFloat(l.Opacity, 1.0),
+		OffsetX:   l.OffsetX,
+		OffsetY:   l.OffsetY,
+		TintColor: l.TintColor,
+		ParallaxX: attrFloat(l.ParallaxX, 1.0),
+		ParallaxY: attrFloat(l.ParallaxY, 1.0),
+
+		Properties: properties.FromXML(l.Properties),
+	}
+
+	switch l.XMLName.Local {
+	case "layer":
+		layerJSON.Type = TileLayer
+		if l.Data == nil {
+			break
+		}
+
+		layerJSON.Encoding = l.Data.Encoding
+		layerJSON.Compression = l.Data.Compression
+
+		if len(l.Data.Chunks) > 0 {
+			layerJSON.Chunks = make([]*ChunkJSON, 0, len(l.Data.Chunks))
+			for _, chunk := range l.Data.Chunks {
+				raw, err := rawJSON(l.Data.Encoding, chunk.Text, chunk.Tiles)
+				if err != nil {
+					return layerJSON, err
+				}
+
+				layerJSON.Chunks = append(layerJSON.Chunks, &ChunkJSON{
+					RawData: raw,
+					X:       chunk.X,
+					Y:       chunk.Y,
+					Width:   chunk.Width,
+					Height:  chunk.Height,
+				})
+			}
+			break
+		}
+
+		raw, err := rawJSON(l.Data.Encoding, l.Data.Text, l.Data.Tiles)
+		if err != nil {
+			return layerJSON, err
+		}
+		layerJSON.RawData = raw
+	case "objectgroup":
+		layerJSON.Type = ObjectGroup
+		layerJSON.Objects = make([]*objects.ObjectJSON, 0, len(l.Objects))
+		for _, obj := range l.Objects {
+			layerJSON.Objects = append(layerJSON.Objects, obj.JSON())
+		}
+	case "imagelayer":
+		layerJSON.Type = ImageLayer
+		layerJSON.RepeatX = l.RepeatX == 1
+		layerJSON.RepeatY = l.RepeatY == 1
+		if l.Image != nil {
+			layerJSON.Image = l.Image.Source
+		}
+	case "group":
+		layerJSON.Type = Group
+
+		children, err := layersJSON(l.Layers)
+		if err != nil {
+			return layerJSON, err
+		}
+		layerJSON.Layers = children
+	}
+	return layerJSON, nil
+}
+
+// Converts the layer elements among layers, skipping anything else that ended up there.
+func layersJSON(layers []LayerXML) ([]TilemapLayerJSON, error) {
+	converted := make([]TilemapLayerJSON, 0, len(layers))
+
+	for i := range layers {
+		switch layers[i].XMLName.Local {
+		case "layer", "objectgroup", "imagelayer", "group":
+		default:
+			continue
+		}
+
+		layerJSON, err := layers[i].JSON()
+		if err != nil {
+			return nil, err
+		}
+		converted = append(converted, layerJSON)
+	}
+	return converted, nil
+}
+
+func (t *TilemapXML) JSON() (*TilemapJSON, error) {
+	layers, err := layersJSON(t.Layers)
+	if err != nil {
+		return nil, err
+	}
+
+	tilemapJSON := &TilemapJSON{
+		Layers:     layers,
+		Tilesets:   make([]TilesetRefJSON, 0, len(t.Tilesets)),
+		Width:      t.Width,
+		Height:     t.Height,
+		TileWidth:  t.TileWidth,
+		TileHeight: t.TileHeight,
+		Infinite:   t.Infinite == 1,
+
+		ParallaxOriginX: t.ParallaxOriginX,
+		ParallaxOriginY: t.ParallaxOriginY,
+
+		Properties: properties.FromXML(t.Properties),
+	}
+
+	for i := range t.Tilesets {
+		ref := TilesetRefJSON{
+			FirstGid: t.Tilesets[i].FirstGid,
+			Source:   t.Tilesets[i].Source,
+		}
+		if ref.Source == "" {
+			ref.TilesetJSON = *t.Tilesets[i].TilesetXML.JSON()
+		}
+		tilemapJSON.Tilesets = append(tilemapJSON.Tilesets, ref)
+	}
+
+	return tilemapJSON, nil
+}
+
+func parseTMX(contents []byte) (*TilemapJSON, error) {
+	var tilemapXML TilemapXML
+	err := xml.Unmarshal(contents, &tilemapXML)
+	if err != nil {
+		return nil, err
+	}
+	return tilemapXML.JSON()
+}
+
+// Maps are XML when the file is a .tmx, or when the content starts with a tag.
+func isTMX(path string, contents []byte) bool {
+	if filepath.Ext(path) == ".tmx" {
+		return true
+	}
+	contents = bytes.TrimPrefix(contents, []byte("\xef\xbb\xbf")) // utf-8 BOM
+	return bytes.HasPrefix(bytes.TrimSpace(contents), []byte("<"))
+}
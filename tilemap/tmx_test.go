@@ -0,0 +1,193 @@
+package tilemap
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// The same small map saved by Tiled as .tmx and as .tmj.
+const testTMX = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="3" height="2" tilewidth="16" tileheight="16" infinite="0" parallaxoriginx="8" parallaxoriginy="4">
+ <properties>
+  <property name="music" type="file" value="town.ogg"/>
+  <property name="dark" type="bool" value="true"/>
+ </properties>
+ <tileset firstgid="1" source="ground.tsx"/>
+ <layer id="1" name="csv" width="3" height="2" opacity="0.5">
+  <data encoding="csv">
+1,2,0,
+3,2147483652,5
+</data>
+ </layer>
+ <group id="2" name="town" offsetx="4" offsety="-2" parallaxx="0.5">
+  <layer id="3" name="base64" width="3" height="2" x="1" y="2" visible="0" tintcolor="#ff8000">
+   <properties>
+    <property name="collides" type="bool" value="false"/>
+   </properties>
+   <data encoding="base64">
+    AQAAAAIAAAAAAAAAAwAAAAQAAIAFAAAA
+   </data>
+  </layer>
+  <objectgroup id="4" name="objects" offsetx="1.5">
+   <object id="1" name="wall" type="collision" x="0" y="8" rotation="45">
+    <polygon points="0,0 16,0 16,16"/>
+   </object>
+   <object id="2" name="spawn" x="24" y="10">
+    <properties>
+     <property name="facing" value="left"/>
+     <property name="hp" type="int" value="3"/>
+     <property name="speed" type="float" value="1.5"/>
+    </properties>
+    <point/>
+   </object>
+   <object id="3" x="2" y="3" width="8" height="6" visible="0"/>
+  </objectgroup>
+ </group>
+ <imagelayer id="5" name="sky" repeatx="1" parallaxy="0.25">
+  <image source="sky.png" width="64" height="32"/>
+ </imagelayer>
+</map>
+`
+
+const testTMJ = `{
+ "compressionlevel": -1,
+ "width": 3,
+ "height": 2,
+ "infinite": false,
+ "orientation": "orthogonal",
+ "parallaxoriginx": 8,
+ "parallaxoriginy": 4,
+ "properties": [
+  {"name": "music", "type": "file", "value": "town.ogg"},
+  {"name": "dark", "type": "bool", "value": true}
+ ],
+ "tilewidth": 16,
+ "tileheight": 16,
+ "tilesets": [{"firstgid": 1, "source": "ground.tsx"}],
+ "layers": [
+  {
+   "id": 1, "name": "csv", "type": "tilelayer",
+   "width": 3, "height": 2, "x": 0, "y": 0,
+   "opacity": 0.5, "visible": true, "encoding": "csv",
+   "data": [1, 2, 0, 3, 2147483652, 5]
+  },
+  {
+   "id": 2, "name": "town", "type": "group",
+   "offsetx": 4, "offsety": -2, "parallaxx": 0.5,
+   "opacity": 1, "visible": true, "x": 0, "y": 0,
+   "layers": [
+    {
+     "id": 3, "name": "base64", "type": "tilelayer",
+     "width": 3, "height": 2, "x": 1, "y": 2,
+     "opacity": 1, "visible": false, "tintcolor": "#ff8000",
+     "encoding": "base64",
+     "data": "AQAAAAIAAAAAAAAAAwAAAAQAAIAFAAAA",
+     "properties": [{"name": "collides", "type": "bool", "value": false}]
+    },
+    {
+     "id": 4, "name": "objects", "type": "objectgroup", "draworder": "topdown",
+     "offsetx": 1.5, "opacity": 1, "visible": true, "x": 0, "y": 0,
+     "objects": [
+      {
+       "id": 1, "name": "wall", "type": "collision",
+       "x": 0, "y": 8, "width": 0, "height": 0, "rotation": 45, "visible": true,
+       "polygon": [{"x": 0, "y": 0}, {"x": 16, "y": 0}, {"x": 16, "y": 16}]
+      },
+      {
+       "id": 2, "name": "spawn", "type": "",
+       "x": 24, "y": 10, "width": 0, "height": 0, "rotation": 0, "visible": true,
+       "point": true,
+       "properties": [
+        {"name": "facing", "type": "string", "value": "left"},
+        {"name": "hp", "type": "int", "value": 3},
+        {"name": "speed", "type": "float", "value": 1.5}
+       ]
+      },
+      {
+       "id": 3, "name": "", "type": "",
+       "x": 2, "y": 3, "width": 8, "height": 6, "rotation": 0, "visible": false
+      }
+     ]
+    }
+   ]
+  },
+  {
+   "id": 5, "name": "sky", "type": "imagelayer",
+   "image": "sky.png", "imagewidth": 64, "imageheight": 32,
+   "repeatx": true, "parallaxy": 0.25,
+   "opacity": 1, "visible": true, "x": 0, "y": 0
+  }
+ ],
+ "nextlayerid": 6,
+ "nextobjectid": 4,
+ "type": "map",
+ "version": "1.10"
+}
+`
+
+// Loads contents saved as name in a directory shared by the test, so maps
+// loaded side by side end up with the same dir.
+func loadTestMap(t *testing.T, dir, name, contents string) *TilemapJSON {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	err := os.WriteFile(path, []byte(contents), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tilemap, err := NewTilemap(path)
+	if err != nil {
+		t.Fatalf("NewTilemap(%s) = %v", name, err)
+	}
+	return tilemap
+}
+
+func TestTMXMatchesTMJ(t *testing.T) {
+	dir := t.TempDir()
+	fromTMX := loadTestMap(t, dir, "town.tmx", testTMX)
+	fromTMJ := loadTestMap(t, dir, "town.tmj", testTMJ)
+
+	// Tiled writes "string" in .tmj where .tmx leaves the type out
+	for _, obj := range fromTMJ.FlatLayers()[2].Objects {
+		for i := range obj.Properties {
+			if obj.Properties[i].Type == "string" {
+				obj.Properties[i].Type = ""
+			}
+		}
+	}
+
+	if len(fromTMX.FlatLayers()) != len(fromTMJ.FlatLayers()) {
+		t.Fatalf("%d layers from the tmx, %d from the tmj", len(fromTMX.FlatLayers()), len(fromTMJ.FlatLayers()))
+	}
+	for i, layer := range fromTMX.FlatLayers() {
+		want := fromTMJ.FlatLayers()[i]
+		if !reflect.DeepEqual(layer, want) {
+			t.Errorf("layer %q from the tmx = %+v, want %+v", layer.Name, *layer, *want)
+		}
+		for j, obj := range layer.Objects {
+			if j < len(want.Objects) && !reflect.DeepEqual(obj, want.Objects[j]) {
+				t.Errorf("object %d of %q from the tmx = %+v, want %+v", obj.Id, layer.Name, *obj, *want.Objects[j])
+			}
+		}
+	}
+	// the layers are in there too, but were already reported one by one
+	if !reflect.DeepEqual(fromTMX, fromTMJ) {
+		t.Errorf("map from the tmx doesn't match the one from the tmj")
+	}
+
+	// spot checks, in case both got it wrong the same way
+	base64 := fromTMX.FlatLayers()[1]
+	wantData := []int{1, 2, 0, 3, 4, 5}
+	if !reflect.DeepEqual(base64.Data, wantData) {
+		t.Errorf("base64 data = %v, want %v", base64.Data, wantData)
+	}
+	if base64.Flip(4) != FlipHorizontal {
+		t.Errorf("flip of the fifth tile = %v, want %v", base64.Flip(4), FlipHorizontal)
+	}
+	if base64.X != 1 || base64.Y != 2 || base64.parent == nil || base64.parent.Name != "town" {
+		t.Errorf("base64 layer at (%d, %d) in %v, want (1, 2) in the town group", base64.X, base64.Y, base64.parent)
+	}
+}
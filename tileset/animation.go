@@ -0,0 +1,40 @@
+package tileset
+
+// One frame of a tile animation set up in Tiled's tileset editor.
+type FrameJSON struct {
+	TileId   int `json:"tileid"`   // local id of the tile to show
+	Duration int `json:"duration"` // milliseconds
+}
+
+// Animation frames of each animated tile, keyed by local tile id.
+func tileAnimations(tiles []*TIleJSON) map[int][]FrameJSON {
+	animations := make(map[int][]FrameJSON)
+
+	for _, tileJSON := range tiles {
+		if len(tileJSON.Animation) == 0 {
+			continue
+		}
+		animations[tileJSON.Id] = tileJSON.Animation
+	}
+	return animations
+}
+
+// FrameAt returns the local tile id to show at time ms of a looping animation.
+func FrameAt(frames []FrameJSON, ms int) int {
+	total := 0
+	for _, frame := range frames {
+		total += frame.Duration
+	}
+	if total <= 0 {
+		return frames[0].TileId
+	}
+
+	ms %= total
+	for _, frame := range frames {
+		if ms < frame.Duration {
+			return frame.TileId
+		}
+		ms -= frame.Duration
+	}
+	return frames[len(frames)-1].TileId
+}
@@ -0,0 +1,48 @@
+package tileset
+
+import "testing"
+
+func TestFrameAt(t *testing.T) {
+	frames := []FrameJSON{
+		{TileId: 4, Duration: 100},
+		{TileId: 5, Duration: 50},
+		{TileId: 6, Duration: 250},
+	}
+
+	tests := []struct {
+		ms   int
+		want int
+	}{
+		{0, 4},
+		{99, 4},
+		{100, 5},
+		{149, 5},
+		{150, 6},
+		{399, 6},
+		// loops every 400ms
+		{400, 4},
+		{520, 5},
+		{4000 + 399, 6},
+	}
+
+	for _, test := range tests {
+		got := FrameAt(frames, test.ms)
+		if got != test.want {
+			t.Errorf("FrameAt(%d) = %d, want %d", test.ms, got, test.want)
+		}
+	}
+}
+
+func TestFrameAtWithoutDuration(t *testing.T) {
+	frames := []FrameJSON{
+		{TileId: 7, Duration: 0},
+		{TileId: 8, Duration: 0},
+	}
+
+	for _, ms := range []int{0, 1, 1000} {
+		got := FrameAt(frames, ms)
+		if got != 7 {
+			t.Errorf("FrameAt(%d) = %d, want the first frame 7", ms, got)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package tileset
+
+import "image"
+
+// "tileoffset" of a tileset, in pixels.
+type TileOffsetJSON struct {
+	X int `json:"x" xml:"x,attr"`
+	Y int `json:"y" xml:"y,attr"`
+}
+
+// Where the images of a tileset go relative to the spot the map puts them,
+// the same for both kinds of tileset.
+type placement struct {
+	tileOffset      image.Point
+	objectAlignment string
+}
+
+func newPlacement(tilesetJSON *TilesetJSON) placement {
+	return placement{
+		tileOffset:      image.Pt(tilesetJSON.TileOffset.X, tilesetJSON.TileOffset.Y),
+		objectAlignment: tilesetJSON.ObjectAlignment,
+	}
+}
+
+func (p placement) TileOffset() image.Point {
+	return p.tileOffset
+}
+
+func (p placement) ObjectAlignment() string {
+	return p.objectAlignment
+}
@@ -2,128 +2,311 @@ package tileset
 
 import (
 	"encoding/json"
+	"encoding/xml"
+	"fmt"
 	"image"
 	"os"
 	"path/filepath"
-	"rpg-game-go/constants"
+	"rpg-game-go/objects"
+	"rpg-game-go/properties"
 	"strings"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 )
 
+// Tile ids passed to a Tileset are local to it, use tilemap.GidResolver to go
+// from the global ids stored in a map to a tileset and local id.
 type Tileset interface {
+	FirstGid() int
 	Img(id int) *ebiten.Image
+	// Collision shapes drawn on the tile in Tiled, relative to the tile image's top left.
+	Colliders(id int) []*objects.ObjectJSON
+	// Animation frames of the tile, nil if it isn't animated.
+	Animation(id int) []FrameJSON
+	// Custom properties set on the tile in Tiled.
+	Properties(id int) properties.Properties
+	// How far every tile image is moved from where the map puts it, in pixels.
+	TileOffset() image.Point
+	// Which point of a tile object's image sits on the object's position, such
+	// as "bottomleft" or "center". Empty or "unspecified" means bottom left.
+	ObjectAlignment() string
 }
 
-type UniformTilsetJSON struct {
-	Path string `json:"image"`
+// A tileset file, either a single image ("image") or an image collection ("tiles[].image").
+type TilesetJSON struct {
+	Name       string      `json:"name"`
+	Path       string      `json:"image"`
+	TileWidth  int         `json:"tilewidth"`
+	TileHeight int         `json:"tileheight"`
+	Columns    int         `json:"columns"`
+	Margin     int         `json:"margin"`  // pixels around the edge of the image
+	Spacing    int         `json:"spacing"` // pixels between tiles
+	Tiles      []*TIleJSON `json:"tiles"`
+
+	TileOffset      TileOffsetJSON `json:"tileoffset"`
+	ObjectAlignment string         `json:"objectalignment"`
+}
+
+// isImageCollection tells an image collection, where every tile has its own
+// image, apart from a tileset cut from a single image.
+func (t *TilesetJSON) isImageCollection() (bool, error) {
+	tileImages := 0
+	for _, tileJSON := range t.Tiles {
+		if tileJSON.Path != "" {
+			tileImages += 1
+		}
+	}
+
+	switch {
+	case t.Path != "" && tileImages > 0:
+		return false, fmt.Errorf("tileset %q has both an \"image\" and %d \"tiles[].image\"", t.Name, tileImages)
+	case t.Path != "":
+		return false, nil
+	case tileImages > 0 && t.Columns != 0:
+		return false, fmt.Errorf("tileset %q has \"tiles[].image\" but %d \"columns\", image collections have 0", t.Name, t.Columns)
+	case tileImages > 0:
+		return true, nil
+	default:
+		return false, fmt.Errorf("tileset %q has neither an \"image\" nor any \"tiles[].image\"", t.Name)
+	}
 }
 
 type UniformTilset struct {
-	img *ebiten.Image
-	gid int
+	placement
+	img        *ebiten.Image
+	gid        int
+	tileWidth  int
+	tileHeight int
+	columns    int
+	margin     int
+	spacing    int
+	colliders  map[int][]*objects.ObjectJSON
+	animations map[int][]FrameJSON
+	properties map[int]properties.Properties
 }
 
-func (u *UniformTilset) Img(id int) *ebiten.Image {
-	// tilesetColumns := g.tilemapImg.Bounds().Dx() / 16 // Number of tiles per row in tileset
-	id -= u.gid
+func (u *UniformTilset) FirstGid() int {
+	return u.gid
+}
 
-	srcX := (id % constants.Tilesize) * 16
-	srcY := (id / constants.Tilesize) * 16
+func (u *UniformTilset) Colliders(id int) []*objects.ObjectJSON {
+	return u.colliders[id]
+}
+
+func (u *UniformTilset) Animation(id int) []FrameJSON {
+	return u.animations[id]
+}
+
+func (u *UniformTilset) Properties(id int) properties.Properties {
+	return u.properties[id]
+}
+
+func (u *UniformTilset) Img(id int) *ebiten.Image {
+	srcX := u.margin + (id%u.columns)*(u.tileWidth+u.spacing)
+	srcY := u.margin + (id/u.columns)*(u.tileHeight+u.spacing)
 
 	return u.img.SubImage(
 		image.Rect(
-			srcX, srcY, srcX+16, srcY+16,
+			srcX, srcY, srcX+u.tileWidth, srcY+u.tileHeight,
 		),
 	).(*ebiten.Image)
 }
 
 type TIleJSON struct {
-	Id     int    `json: "id"`
-	Path   string `json:"image"`
-	Width  int    `json:"imagewidth"`
-	Height int    `json:"imageheight"`
+	Id          int                      `json:"id"`
+	Path        string                   `json:"image"`
+	Width       int                      `json:"imagewidth"`
+	Height      int                      `json:"imageheight"`
+	ObjectGroup *objects.ObjectGroupJSON `json:"objectgroup"`
+	Animation   []FrameJSON              `json:"animation"`
+	Properties  properties.Properties    `json:"properties"`
+}
+
+// Collision shapes of each tile that has any, keyed by local tile id.
+func tileColliders(tiles []*TIleJSON) map[int][]*objects.ObjectJSON {
+	colliders := make(map[int][]*objects.ObjectJSON)
+
+	for _, tileJSON := range tiles {
+		if tileJSON.ObjectGroup == nil || len(tileJSON.ObjectGroup.Objects) == 0 {
+			continue
+		}
+		colliders[tileJSON.Id] = tileJSON.ObjectGroup.Objects
+	}
+	return colliders
 }
 
-// For dynamic tilesets
-type DynTilesetJSON struct {
-	Tiles []*TIleJSON `json:"tiles"`
+// Custom properties of each tile that has any, keyed by local tile id.
+func tileProperties(tiles []*TIleJSON) map[int]properties.Properties {
+	props := make(map[int]properties.Properties)
+
+	for _, tileJSON := range tiles {
+		if len(tileJSON.Properties) == 0 {
+			continue
+		}
+		props[tileJSON.Id] = tileJSON.Properties
+	}
+	return props
 }
 
 type DynTileset struct {
-	 imgs []*ebiten.Image
-	gid  int
+	placement
+	imgs       []*ebiten.Image
+	gid        int
+	colliders  map[int][]*objects.ObjectJSON
+	animations map[int][]FrameJSON
+	properties map[int]properties.Properties
 }
 
-func (d DynTileset) Img(id int) *ebiten.Image {
+func (d DynTileset) FirstGid() int {
+	return d.gid
+}
 
-	id -= d.gid
+func (d DynTileset) Img(id int) *ebiten.Image {
+	if id < 0 || id >= len(d.imgs) {
+		return nil
+	}
 
 	return d.imgs[id]
 }
 
-func NewTileset(path string, gid int) (Tileset, error) {
+func (d DynTileset) Colliders(id int) []*objects.ObjectJSON {
+	return d.colliders[id]
+}
+
+func (d DynTileset) Animation(id int) []FrameJSON {
+	return d.animations[id]
+}
 
+func (d DynTileset) Properties(id int) properties.Properties {
+	return d.properties[id]
+}
+
+// Reads a .tsj, or a .tsx converted to the same model.
+func readTilesetJSON(path string) (*TilesetJSON, error) {
 	contents, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
 
-	if strings.Contains(path, "buildings") || strings.Contains(path, "water") {
-		// return dyn tileset
-		var dynTilesetJSON DynTilesetJSON
-		err = json.Unmarshal(contents, &dynTilesetJSON)
+	if isTSX(path, contents) {
+		var tilesetXML TilesetXML
+		err = xml.Unmarshal(contents, &tilesetXML)
 		if err != nil {
 			return nil, err
 		}
+		return tilesetXML.JSON(), nil
+	}
 
+	var tilesetJSON TilesetJSON
+	err = json.Unmarshal(contents, &tilesetJSON)
+	if err != nil {
+		return nil, err
+	}
+	return &tilesetJSON, nil
+}
+
+// Image paths in tilesets are relative to the file the tileset is defined in,
+// dir is the directory of that file. Tiled only writes absolute paths when
+// there is no relative one, like an image on another drive.
+func imagePath(dir, name string) string {
+	name = filepath.FromSlash(strings.ReplaceAll(name, "\\", "/"))
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(dir, name)
+}
+
+// NewTileset loads an external tileset file (.tsj or .tsx).
+func NewTileset(path string, gid int) (Tileset, error) {
+
+	tilesetJSON, err := readTilesetJSON(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tileset, err := NewTilesetFromJSON(tilesetJSON, gid, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return tileset, nil
+}
+
+// NewTilesetFromJSON builds a tileset from an already decoded definition, such
+// as one embedded in a map. dir is the directory of the file it comes from,
+// its image paths are relative to it.
+func NewTilesetFromJSON(tilesetJSON *TilesetJSON, gid int, dir string) (Tileset, error) {
+	imageCollection, err := tilesetJSON.isImageCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	if imageCollection {
+		// return dyn tileset
 		dynTileset := DynTileset{}
 		dynTileset.gid = gid
+		dynTileset.placement = newPlacement(tilesetJSON)
 		dynTileset.imgs = make([]*ebiten.Image, 0)
+		dynTileset.colliders = tileColliders(tilesetJSON.Tiles)
+		dynTileset.animations = tileAnimations(tilesetJSON.Tiles)
+		dynTileset.properties = tileProperties(tilesetJSON.Tiles)
 
-		for _, tileJSON := range dynTilesetJSON.Tiles {
+		for _, tileJSON := range tilesetJSON.Tiles {
+			// tiles with only collision or animation data don't have an image
+			if tileJSON.Path == "" {
+				continue
+			}
 
-			tileJSONPath := tileJSON.Path
-			tileJSONPath = filepath.Clean(tileJSONPath)
-			tileJSONPath = strings.ReplaceAll(tileJSONPath, "\\", "/")
-			tileJSONPath = strings.TrimPrefix(tileJSONPath, "../")
-			tileJSONPath = strings.TrimPrefix(tileJSONPath, "../")
-			tileJSONPath = filepath.Join("assets/", tileJSONPath)
-			img, _, err := ebitenutil.NewImageFromFile(tileJSONPath)
+			img, _, err := ebitenutil.NewImageFromFile(imagePath(dir, tileJSON.Path))
 			if err != nil {
 				return nil, err
 			}
 
-			dynTileset.imgs = append(dynTileset.imgs, img)
+			// ids have gaps where tiles were removed from the collection
+			for len(dynTileset.imgs) <= tileJSON.Id {
+				dynTileset.imgs = append(dynTileset.imgs, nil)
+			}
+			dynTileset.imgs[tileJSON.Id] = img
 		}
 
 		return &dynTileset, nil
 
 	}
 	// return uniform tilset
-	var uniformTilsetJSON UniformTilsetJSON
-	err = json.Unmarshal(contents, &uniformTilsetJSON)
-	if err != nil {
-		return nil, err
+	// checked before anything is worked out from them, the columns divide by the tile width
+	if tilesetJSON.TileWidth <= 0 || tilesetJSON.TileHeight <= 0 {
+		return nil, fmt.Errorf("tileset %q has no tile size", tilesetJSON.Name)
+	}
+	if tilesetJSON.Margin < 0 || tilesetJSON.Spacing < 0 {
+		return nil, fmt.Errorf("tileset %q has a negative margin or spacing", tilesetJSON.Name)
 	}
 
-	UniformTilset := UniformTilset{}
-
-	tileJSONPath := uniformTilsetJSON.Path
-	tileJSONPath = filepath.Clean(tileJSONPath)
-	tileJSONPath = strings.ReplaceAll(tileJSONPath, "\\", "/")
-	tileJSONPath = strings.TrimPrefix(tileJSONPath, "../")
-	tileJSONPath = strings.TrimPrefix(tileJSONPath, "../")
-	tileJSONPath = filepath.Join("assets/", tileJSONPath)
-
-	img, _, err := ebitenutil.NewImageFromFile(tileJSONPath)
+	img, _, err := ebitenutil.NewImageFromFile(imagePath(dir, tilesetJSON.Path))
 	if err != nil {
 		return nil, err
 	}
 
+	UniformTilset := UniformTilset{}
 	UniformTilset.img = img
 	UniformTilset.gid = gid
+	UniformTilset.placement = newPlacement(tilesetJSON)
+	UniformTilset.tileWidth = tilesetJSON.TileWidth
+	UniformTilset.tileHeight = tilesetJSON.TileHeight
+	UniformTilset.margin = tilesetJSON.Margin
+	UniformTilset.spacing = tilesetJSON.Spacing
+
+	// older files leave columns out, work it out from the image like Tiled does
+	UniformTilset.columns = tilesetJSON.Columns
+	if UniformTilset.columns <= 0 {
+		imageWidth := img.Bounds().Dx()
+		UniformTilset.columns = (imageWidth - 2*tilesetJSON.Margin + tilesetJSON.Spacing) / (tilesetJSON.TileWidth + tilesetJSON.Spacing)
+	}
+	if UniformTilset.columns <= 0 {
+		return nil, fmt.Errorf("tileset %q is narrower than one tile", tilesetJSON.Name)
+	}
+	UniformTilset.colliders = tileColliders(tilesetJSON.Tiles)
+	UniformTilset.animations = tileAnimations(tilesetJSON.Tiles)
+	UniformTilset.properties = tileProperties(tilesetJSON.Tiles)
 
 	return &UniformTilset, nil
 }
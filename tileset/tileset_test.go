@@ -0,0 +1,64 @@
+package tileset
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Broken uniform tilesets are reported before their image is loaded.
+func TestNewTilesetFromJSONErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		tileset TilesetJSON
+	}{
+		{"no tile size", TilesetJSON{Name: "ground", Path: "ground.png"}},
+		{"no tile width", TilesetJSON{Name: "ground", Path: "ground.png", TileHeight: 16}},
+		{"no tile height", TilesetJSON{Name: "ground", Path: "ground.png", TileWidth: 16}},
+		{"negative margin", TilesetJSON{Name: "ground", Path: "ground.png", TileWidth: 16, TileHeight: 16, Margin: -1}},
+		// would divide by zero working out the columns
+		{"spacing cancels the width", TilesetJSON{Name: "ground", Path: "ground.png", TileWidth: 16, TileHeight: 16, Spacing: -16}},
+	}
+
+	for _, test := range tests {
+		ts, err := NewTilesetFromJSON(&test.tileset, 1, "assets/maps")
+		if err == nil {
+			t.Errorf("%s: got %v, want an error", test.name, ts)
+		}
+	}
+}
+
+func TestImagePath(t *testing.T) {
+	tests := []struct {
+		dir, name, want string
+	}{
+		{"assets/maps", "../images/ground.png", "assets/images/ground.png"},
+		{"assets/maps", "ground.png", "assets/maps/ground.png"},
+		{"assets/maps/caves", "../../images/ground.png", "assets/images/ground.png"},
+		// saved on Windows
+		{"assets/maps", `..\images\ground.png`, "assets/images/ground.png"},
+	}
+
+	for _, test := range tests {
+		got := filepath.ToSlash(imagePath(test.dir, test.name))
+		if got != test.want {
+			t.Errorf("imagePath(%q, %q) = %q, want %q", test.dir, test.name, got, test.want)
+		}
+	}
+}
+
+// The ground tileset is saved both ways, they should load the same.
+func TestTSXMatchesTSJ(t *testing.T) {
+	fromTSX, err := readTilesetJSON("../assets/maps/ground.tsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromTSJ, err := readTilesetJSON("../assets/maps/ground.tsj")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(fromTSX, fromTSJ) {
+		t.Errorf("ground.tsx = %+v, want %+v", *fromTSX, *fromTSJ)
+	}
+}
@@ -0,0 +1,89 @@
+package tileset
+
+import (
+	"bytes"
+	"path/filepath"
+	"rpg-game-go/objects"
+	"rpg-game-go/properties"
+)
+
+// XML (.tsx) version of a tileset, converted to TilesetJSON on load.
+
+type ImageXML struct {
+	Source string `xml:"source,attr"`
+	Width  int    `xml:"width,attr"`
+	Height int    `xml:"height,attr"`
+}
+
+type FrameXML struct {
+	TileId   int `xml:"tileid,attr"`
+	Duration int `xml:"duration,attr"`
+}
+
+type TileXML struct {
+	Id          int                      `xml:"id,attr"`
+	Image       *ImageXML                `xml:"image"`
+	ObjectGroup *objects.ObjectGroupXML  `xml:"objectgroup"`
+	Animation   []FrameXML               `xml:"animation>frame"`
+	Properties  []properties.PropertyXML `xml:"properties>property"`
+}
+
+type TilesetXML struct {
+	Name       string     `xml:"name,attr"`
+	TileWidth  int        `xml:"tilewidth,attr"`
+	TileHeight int        `xml:"tileheight,attr"`
+	Columns    int        `xml:"columns,attr"`
+	Margin     int        `xml:"margin,attr"`
+	Spacing    int        `xml:"spacing,attr"`
+	Image      *ImageXML  `xml:"image"`
+	Tiles      []*TileXML `xml:"tile"`
+
+	TileOffset      TileOffsetJSON `xml:"tileoffset"`
+	ObjectAlignment string         `xml:"objectalignment,attr"`
+}
+
+func (t *TilesetXML) JSON() *TilesetJSON {
+	tilesetJSON := &TilesetJSON{
+		Name:       t.Name,
+		TileWidth:  t.TileWidth,
+		TileHeight: t.TileHeight,
+		Columns:    t.Columns,
+		Margin:     t.Margin,
+		Spacing:    t.Spacing,
+
+		TileOffset:      t.TileOffset,
+		ObjectAlignment: t.ObjectAlignment,
+	}
+	if t.Image != nil {
+		tilesetJSON.Path = t.Image.Source
+	}
+
+	for _, tile := range t.Tiles {
+		tileJSON := &TIleJSON{
+			Id:         tile.Id,
+			Properties: properties.FromXML(tile.Properties),
+		}
+		if tile.Image != nil {
+			tileJSON.Path = tile.Image.Source
+			tileJSON.Width = tile.Image.Width
+			tileJSON.Height = tile.Image.Height
+		}
+		if tile.ObjectGroup != nil {
+			tileJSON.ObjectGroup = tile.ObjectGroup.JSON()
+		}
+		for _, frame := range tile.Animation {
+			tileJSON.Animation = append(tileJSON.Animation, FrameJSON(frame))
+		}
+		tilesetJSON.Tiles = append(tilesetJSON.Tiles, tileJSON)
+	}
+	return tilesetJSON
+}
+
+// Tilesets are XML when the file is a .tsx, or when the content starts with a tag.
+func isTSX(path string, contents []byte) bool {
+	if filepath.Ext(path) == ".tsx" {
+		return true
+	}
+	contents = bytes.TrimPrefix(contents, []byte("\xef\xbb\xbf")) // utf-8 BOM
+	return bytes.HasPrefix(bytes.TrimSpace(contents), []byte("<"))
+}
@@ -0,0 +1,53 @@
+package world
+
+import (
+	"image"
+	"math"
+	"rpg-game-go/tilemap"
+)
+
+// Object type of portals, in any object layer of a map.
+const PortalType = "portal"
+
+// A portal takes the player to a spawn point, set with the custom properties
+// "map" (a file) and "spawn" (the name of an object in the target map's spawn
+// layer). Without "map" the spawn point is in the same map, without "spawn"
+// it is the map's player_start.
+type Portal struct {
+	Bounds image.Rectangle
+	Map    string // path of the target map on disk, empty for the same map
+	Spawn  string
+}
+
+// Portals finds the portal objects in every object layer of the map, placed
+// by the layer's offset. Layers with parallax are skipped.
+func Portals(t *tilemap.TilemapJSON) []Portal {
+	portals := make([]Portal, 0)
+
+	for _, layer := range t.FlatLayers() {
+		if layer.Type != tilemap.ObjectGroup || layer.HasParallax() {
+			continue
+		}
+		offsetX, offsetY := layer.WorldOffset(t)
+		offset := image.Pt(int(math.Round(offsetX)), int(math.Round(offsetY)))
+
+		for _, obj := range layer.Objects {
+			if obj.Kind() != PortalType {
+				continue
+			}
+
+			portal := Portal{
+				Bounds: obj.Bounds().Add(offset),
+				Map:    "",
+				Spawn:  obj.Properties.String("spawn", ""),
+			}
+
+			target := obj.Properties.File("map", "")
+			if target != "" {
+				portal.Map = t.FilePath(target)
+			}
+			portals = append(portals, portal)
+		}
+	}
+	return portals
+}
@@ -0,0 +1,53 @@
+package world
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"rpg-game-go/tilemap"
+	"testing"
+)
+
+// Portals in a group moved by (10, -5) and in a parallax layer.
+const portalMap = `{
+ "width": 4, "height": 4, "tilewidth": 16, "tileheight": 16,
+ "layers": [
+  {
+   "name": "doors", "type": "group", "offsetx": 10, "offsety": -5,
+   "layers": [{
+    "name": "portals", "type": "objectgroup", "x": 1, "offsety": 0.4, "visible": false,
+    "objects": [{"type": "portal", "x": 4, "y": 8, "width": 16, "height": 8,
+     "properties": [{"name": "map", "type": "file", "value": "east.tmj"}]}]
+   }]
+  },
+  {
+   "name": "sky", "type": "objectgroup", "parallaxx": 0.5,
+   "objects": [{"type": "portal", "x": 0, "y": 0, "width": 16, "height": 16}]
+  }
+ ]
+}`
+
+func TestPortalsOffset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "town.tmj")
+	err := os.WriteFile(path, []byte(portalMap), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := tilemap.NewTilemap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	portals := Portals(m)
+	if len(portals) != 1 {
+		t.Fatalf("%d portals, want only the one outside the parallax layer", len(portals))
+	}
+
+	want := image.Rect(30, 3, 46, 11)
+	if portals[0].Bounds != want {
+		t.Errorf("portal bounds = %v, want %v", portals[0].Bounds, want)
+	}
+	if portals[0].Map != filepath.Join(filepath.Dir(path), "east.tmj") {
+		t.Errorf("portal map = %q, want east.tmj next to the map", portals[0].Map)
+	}
+}
@@ -0,0 +1,66 @@
+package world
+
+import (
+	"encoding/json"
+	"image"
+	"os"
+	"path"
+)
+
+// A map placed in a Tiled .world file, X and Y in world pixels.
+type MapRefJSON struct {
+	FileName string `json:"fileName"` // relative to the .world file
+	X        int    `json:"x"`
+	Y        int    `json:"y"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+}
+
+// A Tiled .world file, laying out maps next to each other. Only the explicit
+// "maps" list is read, "patterns" are not supported.
+type WorldJSON struct {
+	Maps []*MapRefJSON `json:"maps"`
+
+	dir string // directory of the .world file
+}
+
+func NewWorld(filepath string) (*WorldJSON, error) {
+	contents, err := os.ReadFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	var worldJSON WorldJSON
+	err = json.Unmarshal(contents, &worldJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	worldJSON.dir = path.Dir(filepath)
+	return &worldJSON, nil
+}
+
+// MapPath is where the map file is on disk.
+func (w *WorldJSON) MapPath(m *MapRefJSON) string {
+	return path.Join(w.dir, m.FileName)
+}
+
+// Find returns the world's entry for the map file at mapPath, nil if the map isn't part of the world.
+func (w *WorldJSON) Find(mapPath string) *MapRefJSON {
+	for _, m := range w.Maps {
+		if w.MapPath(m) == path.Clean(mapPath) {
+			return m
+		}
+	}
+	return nil
+}
+
+// MapAt returns the map covering the point (x, y) in world pixels, nil if there is none.
+func (w *WorldJSON) MapAt(x, y int) *MapRefJSON {
+	for _, m := range w.Maps {
+		if image.Pt(x, y).In(image.Rect(m.X, m.Y, m.X+m.Width, m.Y+m.Height)) {
+			return m
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package world
+
+import "testing"
+
+func TestMapAt(t *testing.T) {
+	spawn := &MapRefJSON{FileName: "spawn.tmj", X: 0, Y: 0, Width: 1600, Height: 1280}
+	east := &MapRefJSON{FileName: "east.tmj", X: 1600, Y: 0, Width: 480, Height: 320}
+	north := &MapRefJSON{FileName: "north.tmj", X: -200, Y: -640, Width: 800, Height: 640}
+	w := &WorldJSON{Maps: []*MapRefJSON{spawn, east, north}}
+
+	tests := []struct {
+		name string
+		x, y int
+		want *MapRefJSON
+	}{
+		{"origin", 0, 0, spawn},
+		{"inside", 800, 600, spawn},
+		{"last pixel", 1599, 1279, spawn},
+		// the right edge belongs to the neighbour
+		{"shared edge", 1600, 100, east},
+		{"inside the neighbour", 2079, 319, east},
+		{"past the neighbour", 2080, 100, nil},
+		{"below the neighbour", 1700, 320, nil},
+		{"negative coordinates", -200, -640, north},
+		{"above the world", 900, -1, nil},
+		{"left of the world", -1, 100, nil},
+	}
+
+	for _, test := range tests {
+		got := w.MapAt(test.x, test.y)
+		if got != test.want {
+			t.Errorf("%s: MapAt(%d, %d) = %v, want %v", test.name, test.x, test.y, got, test.want)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	w := &WorldJSON{
+		Maps: []*MapRefJSON{{FileName: "spawn.tmj"}, {FileName: "caves/east.tmj"}},
+		dir:  "assets/maps",
+	}
+
+	if got := w.Find("assets/maps/caves/../caves/east.tmj"); got != w.Maps[1] {
+		t.Errorf("Find = %v, want %v", got, w.Maps[1])
+	}
+	if got := w.Find("assets/maps/missing.tmj"); got != nil {
+		t.Errorf("Find of a map outside the world = %v, want nil", got)
+	}
+}